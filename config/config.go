@@ -5,11 +5,13 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"time"
 
 	yaml "github.com/go-yaml/yaml"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/middleware"
+	gommonLog "github.com/labstack/gommon/log"
 )
 
 // Environment in which the application runs
@@ -24,19 +26,90 @@ var Environments = map[string]Environment{
 
 // Config contains the necessary application configuration
 type Config struct {
-	ConnectionString string `yaml:"connection_string"`
-	Database         string `yaml:"database"`
-	Debug            bool   `yaml:"debug"`
-	Environment      Environment
-	JWTKey           string `yaml:"JWT_key"`
-	MigrationsPath   string `yaml:"migrations_path"`
+	ConnectionString       string `yaml:"connection_string"`
+	Database               string `yaml:"database"`
+	Debug                  bool   `yaml:"debug"`
+	Environment            Environment
+	IdempotencyKeyTTLHours int    `yaml:"idempotency_key_ttl_hours"`
+	JWTKey                 string `yaml:"JWT_key"`
+	LogLevel               string `yaml:"log_level"`
+	LogOutput              string `yaml:"log_output"`
+	MigrationsPath         string `yaml:"migrations_path"`
+	ShutdownTimeout        int    `yaml:"shutdown_timeout"`
+	TrashRetentionDays     int    `yaml:"trash_retention_days"`
 }
 
+// LogLevels contains all the possible log levels
+var LogLevels = map[string]gommonLog.Lvl{
+	"debug": gommonLog.DEBUG,
+	"info":  gommonLog.INFO,
+	"warn":  gommonLog.WARN,
+	"error": gommonLog.ERROR,
+	"off":   gommonLog.OFF,
+}
+
+// defaultTrashRetention is used when no `trash_retention_days` is configured
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// defaultIdempotencyKeyTTL is used when no `idempotency_key_ttl_hours` is configured
+const defaultIdempotencyKeyTTL = 24 * time.Hour
+
+// defaultLogOutput is used when no `log_output` is configured
+const defaultLogOutput = "error.log"
+
+// defaultShutdownTimeout is used when no `shutdown_timeout` is configured
+const defaultShutdownTimeout = 10 * time.Second
+
 // GetCompleteConnectionString returns the connection string based on the current config
 func (config Config) GetCompleteConnectionString() string {
 	return fmt.Sprintf("%s%s", config.ConnectionString, config.Database)
 }
 
+// GetShutdownTimeout returns how long in-flight requests may take to finish when shutting down
+func (config Config) GetShutdownTimeout() time.Duration {
+	if config.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+
+	return time.Duration(config.ShutdownTimeout) * time.Second
+}
+
+// GetTrashRetention returns how long deleted logs are kept before they're purged
+func (config Config) GetTrashRetention() time.Duration {
+	if config.TrashRetentionDays <= 0 {
+		return defaultTrashRetention
+	}
+
+	return time.Duration(config.TrashRetentionDays) * 24 * time.Hour
+}
+
+// GetIdempotencyKeyTTL returns how long the response to a request with an idempotency key is replayed
+func (config Config) GetIdempotencyKeyTTL() time.Duration {
+	if config.IdempotencyKeyTTLHours <= 0 {
+		return defaultIdempotencyKeyTTL
+	}
+
+	return time.Duration(config.IdempotencyKeyTTLHours) * time.Hour
+}
+
+// GetLogLevel returns the configured log level, defaults to info
+func (config Config) GetLogLevel() gommonLog.Lvl {
+	if level, ok := LogLevels[config.LogLevel]; ok {
+		return level
+	}
+
+	return gommonLog.INFO
+}
+
+// GetLogOutput returns the path of the file logs are written to next to stderr
+func (config Config) GetLogOutput() string {
+	if config.LogOutput == "" {
+		return defaultLogOutput
+	}
+
+	return config.LogOutput
+}
+
 // Local static variables
 var config = Config{}
 var environment = Environments["dev"]
@@ -53,15 +126,8 @@ func GetConfig() Config {
 		return config
 	}
 
-	// Get config file path
-	// @TODO: change how file path is handled
-	appPath := os.Getenv("APP_PATH")
-	if appPath == "" {
-		appPath = fmt.Sprintf("%s/src/github.com/antonve/logger-api", os.Getenv("GOPATH"))
-	}
-
 	// Load config file data
-	configData, err := ioutil.ReadFile(fmt.Sprintf("%s/config/%s", appPath, environment))
+	configData, err := ioutil.ReadFile(fmt.Sprintf("%s/config/%s", GetAppPath(), environment))
 	if err != nil {
 		log.Fatalf("Could not load config for environment `%s`", environment)
 	}
@@ -78,6 +144,17 @@ func GetConfig() Config {
 	return config
 }
 
+// GetAppPath returns the path the application and its data files live in
+// @TODO: change how file path is handled
+func GetAppPath() string {
+	appPath := os.Getenv("APP_PATH")
+	if appPath == "" {
+		appPath = fmt.Sprintf("%s/src/github.com/antonve/logger-api", os.Getenv("GOPATH"))
+	}
+
+	return appPath
+}
+
 // GetJWTConfig returns the JWT config
 func GetJWTConfig(claims jwt.Claims) middleware.JWTConfig {
 	return middleware.JWTConfig{
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// APIActivitiesGetAll gets all custom activities of the current user
+func APIActivitiesGetAll(context echo.Context) error {
+	customActivityCollection := models.CustomActivityCollection{Activities: make([]models.CustomActivity, 0)}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := customActivityCollection.GetAllFromUser(user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, customActivityCollection)
+}
+
+// APIActivitiesPost adds a custom activity
+func APIActivitiesPost(context echo.Context) error {
+	customActivity := &models.CustomActivity{}
+
+	// Attempt to bind request to CustomActivity struct
+	err := context.Bind(customActivity)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+	customActivity.UserID = user.ID
+
+	// Validate request
+	err = customActivity.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	// Save to database
+	customActivityCollection := models.CustomActivityCollection{}
+	id, err := customActivityCollection.Add(customActivity)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	customActivity, err = customActivityCollection.Get(id)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	context.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/activities/%d", customActivity.ID))
+
+	return context.JSON(http.StatusCreated, customActivity)
+}
+
+// APIActivitiesGetByID gets a single custom activity
+func APIActivitiesGetByID(context echo.Context) error {
+	customActivity, status, err := getOwnedCustomActivity(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	return context.JSON(http.StatusOK, customActivity)
+}
+
+// APIActivitiesUpdate updates a custom activity
+func APIActivitiesUpdate(context echo.Context) error {
+	customActivity := &models.CustomActivity{}
+
+	// Attempt to bind request to CustomActivity struct
+	err := context.Bind(customActivity)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	currentCustomActivity, status, err := getOwnedCustomActivity(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	customActivity.ID = currentCustomActivity.ID
+	customActivity.UserID = currentCustomActivity.UserID
+
+	// Validate request
+	err = customActivity.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	customActivityCollection := models.CustomActivityCollection{}
+	err = customActivityCollection.Update(customActivity)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	customActivity, err = customActivityCollection.Get(customActivity.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, customActivity)
+}
+
+// APIActivitiesDelete deletes a custom activity, logs with the activity are kept under its category
+func APIActivitiesDelete(context echo.Context) error {
+	customActivity, status, err := getOwnedCustomActivity(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	customActivityCollection := models.CustomActivityCollection{}
+	err = customActivityCollection.Delete(customActivity, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return Serve(context, 200)
+}
+
+// getOwnedCustomActivity gets the custom activity in the `id` route parameter when it belongs to the current user,
+// otherwise returns the error along with the status to serve it with
+func getOwnedCustomActivity(context echo.Context) (*models.CustomActivity, int, error) {
+	id, err := parseID(context)
+	if err != nil {
+		return nil, 400, err
+	}
+
+	customActivityCollection := models.CustomActivityCollection{}
+	customActivity, err := customActivityCollection.Get(id)
+	if err != nil {
+		return nil, 500, err
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return nil, 500, fmt.Errorf("could not receive user")
+	}
+
+	if !customActivity.IsOwner(user.ID) {
+		return nil, 403, fmt.Errorf("activity doesn't belong to user")
+	}
+
+	return customActivity, 0, nil
+}
@@ -0,0 +1,137 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/antonve/logger-api/utils"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockActivitiesJwtToken string
+var mockActivitiesUser *models.User
+
+func init() {
+	utils.SetupTesting()
+	mockActivitiesJwtToken, mockActivitiesUser = utils.SetupTestUser("activities_test")
+}
+
+func TestActivityPost(t *testing.T) {
+	// Setup create activity request
+	e := echo.New()
+	activityBody := strings.NewReader(`{
+    "name": "Shadowing",
+    "category": "LISTENING"
+  }`)
+	req := httptest.NewRequest(echo.POST, "/api/activities", activityBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockActivitiesJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIActivitiesPost)(c)) {
+		var body models.CustomActivity
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		assert.NotEqual(t, uint64(0), body.ID)
+		assert.Equal(t, "Shadowing", body.Name)
+		assert.Equal(t, enums.ActivityListening, body.Category)
+		assert.Equal(t, fmt.Sprintf("/api/activities/%d", body.ID), rec.Header().Get(echo.HeaderLocation))
+	}
+
+	// Names are unique regardless of case
+	activityBody = strings.NewReader(`{
+    "name": "SHADOWING",
+    "category": "OTHER"
+  }`)
+	req = httptest.NewRequest(echo.POST, "/api/activities", activityBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockActivitiesJwtToken))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIActivitiesPost)(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "another activity already has this name")
+	}
+}
+
+func TestActivityLogs(t *testing.T) {
+	// Setup custom activity
+	customActivityCollection := models.CustomActivityCollection{}
+	activityID, err := customActivityCollection.Add(&models.CustomActivity{UserID: mockActivitiesUser.ID, Name: "Writing", Category: enums.ActivityOther})
+	assert.Nil(t, err)
+
+	// Logs with a custom activity get its category
+	e := echo.New()
+	logBody := strings.NewReader(fmt.Sprintf(`{
+    "language": "JA",
+    "date": "2017-06-01",
+    "duration": 30,
+    "custom_activity_id": %d,
+    "notes": {"comment": "Diary entry"}
+  }`, activityID))
+	req := httptest.NewRequest(echo.POST, "/api/logs", logBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockActivitiesJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var log models.Log
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &log))
+		assert.Equal(t, enums.ActivityOther, log.Activity)
+		if assert.NotNil(t, log.CustomActivityID) {
+			assert.Equal(t, activityID, *log.CustomActivityID)
+		}
+	}
+
+	// The activity has to match the category
+	logCollection := models.LogCollection{}
+	_, err = logCollection.Add(&models.Log{UserID: mockActivitiesUser.ID, Language: enums.LanguageJapanese, Date: "2017-06-01", Duration: 30, Activity: enums.ActivityReading, CustomActivityID: &activityID}, mockActivitiesUser.ID)
+	assert.IsType(t, models.ValidationErrors{}, err)
+
+	// Custom activities of other users can't be used
+	otherActivityID, _ := customActivityCollection.Add(&models.CustomActivity{UserID: mockLogsUser.ID, Name: "Writing", Category: enums.ActivityOther})
+	_, err = logCollection.Add(&models.Log{UserID: mockActivitiesUser.ID, Language: enums.LanguageJapanese, Date: "2017-06-01", Duration: 30, CustomActivityID: &otherActivityID}, mockActivitiesUser.ID)
+	assert.IsType(t, models.ValidationErrors{}, err)
+
+	// The category can't change once logs use the activity
+	err = customActivityCollection.Update(&models.CustomActivity{ID: activityID, UserID: mockActivitiesUser.ID, Name: "Writing", Category: enums.ActivityTranslation})
+	assert.IsType(t, models.ValidationErrors{}, err)
+
+	// Setup delete request
+	req = httptest.NewRequest(echo.DELETE, fmt.Sprintf("/api/activities/%d", activityID), nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockActivitiesJwtToken))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetPath("/api/activities/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", activityID))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIActivitiesDelete)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// The log is kept under the category
+		log, err := logCollection.Get(log.ID)
+		assert.Nil(t, err)
+		assert.Nil(t, log.CustomActivityID)
+		assert.Equal(t, enums.ActivityOther, log.Activity)
+		assert.Equal(t, uint64(2), log.Version)
+
+		_, err = customActivityCollection.Get(activityID)
+		assert.IsType(t, &models.NotFoundError{}, err)
+	}
+}
@@ -0,0 +1,14 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/antonve/logger-api/config"
+
+	"github.com/labstack/echo"
+)
+
+// APIOpenAPISpec serves the OpenAPI document describing this API
+func APIOpenAPISpec(context echo.Context) error {
+	return context.File(fmt.Sprintf("%s/data/openapi.json", config.GetAppPath()))
+}
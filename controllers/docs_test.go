@@ -0,0 +1,246 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/antonve/logger-api/utils"
+
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+type OpenAPISchema struct {
+	Ref        string                   `json:"$ref"`
+	AllOf      []OpenAPISchema          `json:"allOf"`
+	Required   []string                 `json:"required"`
+	Properties map[string]OpenAPISchema `json:"properties"`
+	Enum       []interface{}            `json:"enum"`
+}
+
+type OpenAPISpec struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]OpenAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+var routeParamRegex = regexp.MustCompile(`:(\w+)`)
+
+func loadOpenAPISpec(t *testing.T) *OpenAPISpec {
+	data, err := ioutil.ReadFile(fmt.Sprintf("%s/data/openapi.json", config.GetAppPath()))
+	assert.Nil(t, err)
+
+	spec := &OpenAPISpec{}
+	assert.Nil(t, json.Unmarshal(data, spec))
+
+	return spec
+}
+
+// resolveSchema flattens references and compositions into a single schema
+func (spec *OpenAPISpec) resolveSchema(schema OpenAPISchema) OpenAPISchema {
+	if schema.Ref != "" {
+		return spec.resolveSchema(spec.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")])
+	}
+
+	resolved := OpenAPISchema{Required: schema.Required, Properties: map[string]OpenAPISchema{}, Enum: schema.Enum}
+	for name, property := range schema.Properties {
+		resolved.Properties[name] = property
+	}
+	for _, part := range schema.AllOf {
+		part = spec.resolveSchema(part)
+		resolved.Required = append(resolved.Required, part.Required...)
+		for name, property := range part.Properties {
+			resolved.Properties[name] = property
+		}
+	}
+
+	return resolved
+}
+
+// assertMatchesSchema checks a response body only contains documented fields and has all required ones
+func assertMatchesSchema(t *testing.T, spec *OpenAPISpec, schemaName string, body map[string]interface{}) {
+	schema := spec.resolveSchema(OpenAPISchema{Ref: "#/components/schemas/" + schemaName})
+
+	for _, field := range schema.Required {
+		_, ok := body[field]
+		assert.True(t, ok, "required field `%s` of %s is missing from the response", field, schemaName)
+	}
+	for field := range body {
+		_, ok := schema.Properties[field]
+		assert.True(t, ok, "field `%s` is not documented in %s", field, schemaName)
+	}
+}
+
+func assertEnum(t *testing.T, spec *OpenAPISpec, schemaName string, expected []string) {
+	values := make([]string, 0)
+	for _, value := range spec.Components.Schemas[schemaName].Enum {
+		values = append(values, fmt.Sprintf("%v", value))
+	}
+
+	sort.Strings(values)
+	sort.Strings(expected)
+	assert.Equal(t, expected, values, "enum %s is out of date", schemaName)
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, controllers.APIOpenAPISpec(c)) {
+		var body OpenAPISpec
+		assert.Equal(t, http.StatusOK, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(body.OpenAPI, "3."))
+	}
+}
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+
+	e := echo.New()
+	utils.SetupRouting(e)
+
+	routes := make(map[string]bool)
+	for _, route := range e.Routes() {
+		// Skip the catch-all routes echo registers for group middleware
+		if strings.HasSuffix(route.Path, "/*") || strings.Contains(route.Name, "(*Group).Use") {
+			continue
+		}
+
+		path := routeParamRegex.ReplaceAllString(route.Path, "{$1}")
+		routes[fmt.Sprintf("%s %s", route.Method, path)] = true
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			documented[fmt.Sprintf("%s %s", strings.ToUpper(method), path)] = true
+		}
+	}
+
+	for route := range routes {
+		assert.True(t, documented[route], "route `%s` is not documented", route)
+	}
+	for route := range documented {
+		assert.True(t, routes[route], "documented route `%s` does not exist", route)
+	}
+}
+
+func TestOpenAPISpecEnums(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+
+	assertEnum(t, spec, "Activity", []string{
+		string(enums.ActivityFlashcards),
+		string(enums.ActivityTextbook),
+		string(enums.ActivityReading),
+		string(enums.ActivityListening),
+		string(enums.ActivityTranslation),
+		string(enums.ActivityGrammar),
+		string(enums.ActivityOther),
+	})
+	assertEnum(t, spec, "ReadingType", []string{
+		string(enums.ReadingTypeBook),
+		string(enums.ReadingTypeManga),
+		string(enums.ReadingTypeArticle),
+		string(enums.ReadingTypeNews),
+		string(enums.ReadingTypeWeb),
+		string(enums.ReadingTypeOther),
+	})
+	assertEnum(t, spec, "ResourceType", []string{
+		string(enums.ResourceTypeBook),
+		string(enums.ResourceTypeShow),
+		string(enums.ResourceTypePodcast),
+		string(enums.ResourceTypeDeck),
+		string(enums.ResourceTypeTextbook),
+		string(enums.ResourceTypeOther),
+	})
+	assertEnum(t, spec, "TimerMode", []string{
+		string(enums.TimerModeStandard),
+		string(enums.TimerModePomodoro),
+	})
+	assertEnum(t, spec, "TimerPhase", []string{
+		string(enums.TimerPhaseFocus),
+		string(enums.TimerPhaseBreak),
+	})
+	assertEnum(t, spec, "LogAction", []string{
+		string(enums.LogActionCreate),
+		string(enums.LogActionUpdate),
+		string(enums.LogActionDelete),
+		string(enums.LogActionRestore),
+	})
+	assertEnum(t, spec, "Role", []string{
+		string(enums.RoleAdmin),
+		string(enums.RoleUser),
+		string(enums.RoleDisabled),
+	})
+}
+
+func TestOpenAPISpecMatchesLogResponse(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+
+	// Setup log to grab
+	log := models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-02-05", Duration: 20, Activity: enums.ActivityReading}
+	logCollection := models.LogCollection{}
+	id, _ := logCollection.Add(&log, log.UserID)
+
+	// Setup log request
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/logs/%d", id), nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", id))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetByID)(c)) {
+		var body map[string]interface{}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		assertMatchesSchema(t, spec, "Log", body)
+	}
+}
+
+func TestOpenAPISpecMatchesErrorResponse(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+
+	// Setup log request with a malformed id
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/logs/abc", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("abc")
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetByID)(c)) {
+		var body map[string]interface{}
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		assertMatchesSchema(t, spec, "Error", body)
+	}
+}
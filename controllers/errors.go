@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// Error codes sent along with failed requests, clients rely on these so they should never change
+const (
+	ErrorCodeBadRequest            = "BAD_REQUEST"
+	ErrorCodeInvalidBody           = "INVALID_BODY"
+	ErrorCodeInvalidID             = "INVALID_ID"
+	ErrorCodeValidationFailed      = "VALIDATION_FAILED"
+	ErrorCodeUnauthorized          = "UNAUTHORIZED"
+	ErrorCodeForbidden             = "FORBIDDEN"
+	ErrorCodeNotFound              = "NOT_FOUND"
+	ErrorCodeLogNotFound           = "LOG_NOT_FOUND"
+	ErrorCodeUserNotFound          = "USER_NOT_FOUND"
+	ErrorCodeResourceNotFound      = "RESOURCE_NOT_FOUND"
+	ErrorCodeTagNotFound           = "TAG_NOT_FOUND"
+	ErrorCodeActivityNotFound      = "ACTIVITY_NOT_FOUND"
+	ErrorCodeTemplateNotFound      = "TEMPLATE_NOT_FOUND"
+	ErrorCodeTimerNotFound         = "TIMER_NOT_FOUND"
+	ErrorCodeMethodNotAllowed      = "METHOD_NOT_ALLOWED"
+	ErrorCodeConflict              = "CONFLICT"
+	ErrorCodeVersionConflict       = "VERSION_CONFLICT"
+	ErrorCodeLogOverlap            = "LOG_OVERLAP"
+	ErrorCodeInvalidCursor         = "INVALID_CURSOR"
+	ErrorCodeTimerAlreadyStarted   = "TIMER_ALREADY_STARTED"
+	ErrorCodeTimerNotRunning       = "TIMER_NOT_RUNNING"
+	ErrorCodeTimerNotPaused        = "TIMER_NOT_PAUSED"
+	ErrorCodeTimerNotPomodoro      = "TIMER_NOT_POMODORO"
+	ErrorCodeTimerOnBreak          = "TIMER_ON_BREAK"
+	ErrorCodeTimerNotOnBreak       = "TIMER_NOT_ON_BREAK"
+	ErrorCodeInvalidIdempotencyKey = "INVALID_IDEMPOTENCY_KEY"
+	ErrorCodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	ErrorCodeIdempotencyKeyInUse   = "IDEMPOTENCY_KEY_IN_USE"
+	ErrorCodeNotApplied            = "NOT_APPLIED"
+	ErrorCodeInternal              = "INTERNAL_ERROR"
+)
+
+// statusErrorCodes are the codes used when an error doesn't carry a more specific one
+var statusErrorCodes = map[int]string{
+	http.StatusBadRequest:          ErrorCodeBadRequest,
+	http.StatusUnauthorized:        ErrorCodeUnauthorized,
+	http.StatusForbidden:           ErrorCodeForbidden,
+	http.StatusNotFound:            ErrorCodeNotFound,
+	http.StatusMethodNotAllowed:    ErrorCodeMethodNotAllowed,
+	http.StatusConflict:            ErrorCodeConflict,
+	http.StatusInternalServerError: ErrorCodeInternal,
+}
+
+// notFoundErrorCodes maps the resource of a models.NotFoundError to its error code
+var notFoundErrorCodes = map[string]string{
+	"log":      ErrorCodeLogNotFound,
+	"user":     ErrorCodeUserNotFound,
+	"resource": ErrorCodeResourceNotFound,
+	"tag":      ErrorCodeTagNotFound,
+	"activity": ErrorCodeActivityNotFound,
+	"template": ErrorCodeTemplateNotFound,
+}
+
+// ErrorResponse is the body sent along with every failed request
+type ErrorResponse struct {
+	Success bool                    `json:"success"`
+	Status  int                     `json:"status"`
+	Code    string                  `json:"code"`
+	Message string                  `json:"message"`
+	Details models.ValidationErrors `json:"details,omitempty"`
+}
+
+// codedError attaches an error code to an error
+type codedError struct {
+	code string
+	err  error
+}
+
+func (codedError *codedError) Error() string {
+	return codedError.err.Error()
+}
+
+// withCode makes sure the error is served with the given error code
+func withCode(code string, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// newErrorResponse maps an error onto the response it should be served with,
+// statusCode is used when the error itself doesn't determine the status
+func newErrorResponse(statusCode int, err error) ErrorResponse {
+	response := ErrorResponse{Status: statusCode}
+
+	switch typedErr := err.(type) {
+	case *codedError:
+		response.Code = typedErr.code
+	case models.ValidationErrors:
+		response.Status = http.StatusBadRequest
+		response.Code = ErrorCodeValidationFailed
+		response.Details = typedErr
+	case *models.NotFoundError:
+		response.Status = http.StatusNotFound
+		response.Code = notFoundErrorCodes[typedErr.Resource]
+	case *models.VersionConflictError:
+		response.Status = http.StatusConflict
+		response.Code = ErrorCodeVersionConflict
+	case *models.LogOverlapError:
+		response.Status = http.StatusConflict
+		response.Code = ErrorCodeLogOverlap
+	case *echo.HTTPError:
+		response.Status = typedErr.Code
+		err = fmt.Errorf("%v", typedErr.Message)
+	default:
+		if err == sql.ErrNoRows {
+			response.Status = http.StatusNotFound
+		}
+	}
+
+	if response.Code == "" {
+		response.Code = statusErrorCodes[response.Status]
+	}
+	if response.Code == "" {
+		response.Code = ErrorCodeInternal
+	}
+
+	// Never leak internal errors to the client
+	if response.Status >= http.StatusInternalServerError || err == nil {
+		response.Message = http.StatusText(response.Status)
+	} else {
+		response.Message = err.Error()
+	}
+
+	return response
+}
+
+// HTTPErrorHandler serves errors returned by handlers and middleware in the same format as ServeWithError
+func HTTPErrorHandler(err error, context echo.Context) {
+	if context.Response().Committed {
+		return
+	}
+
+	statusCode := http.StatusInternalServerError
+	if _, ok := err.(*echo.HTTPError); !ok {
+		handleError(context, err)
+	}
+
+	response := newErrorResponse(statusCode, err)
+	if context.Request().Method == echo.HEAD {
+		err = context.NoContent(response.Status)
+	} else {
+		err = context.JSON(response.Status, response)
+	}
+	if err != nil {
+		handleError(context, err)
+	}
+}
+
+// formatETag formats the version of a record as an entity tag
+func formatETag(version uint64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseIfMatch parses the version in the If-Match header, returns 0 when the header isn't set
+func parseIfMatch(context echo.Context) (uint64, error) {
+	ifMatch := strings.TrimSpace(context.Request().Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`), 10, 64)
+	if err != nil || version == 0 {
+		return 0, fmt.Errorf("invalid If-Match header `%s` supplied", ifMatch)
+	}
+
+	return version, nil
+}
+
+// parseLimit parses the optional `limit` query parameter, returns 0 when it isn't set
+func parseLimit(context echo.Context) (int, error) {
+	if context.QueryParam("limit") == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(context.QueryParam("limit"))
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid limit `%s` supplied", context.QueryParam("limit"))
+	}
+
+	return limit, nil
+}
+
+// parseID parses the `id` route parameter
+func parseID(context echo.Context) (uint64, error) {
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		return 0, withCode(ErrorCodeInvalidID, fmt.Errorf("invalid id `%s` supplied", context.Param("id")))
+	}
+
+	return id, nil
+}
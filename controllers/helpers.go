@@ -1,10 +1,6 @@
 package controllers
 
 import (
-	"fmt"
-	"log"
-	"net/http"
-
 	"github.com/antonve/logger-api/config"
 	"github.com/antonve/logger-api/models"
 
@@ -12,6 +8,7 @@ import (
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
 )
 
 // Serve a successful request
@@ -21,16 +18,10 @@ func Serve(context echo.Context, statusCode int) error {
 
 // Serve a request with errors
 func ServeWithError(context echo.Context, statusCode int, err error) error {
-	handleError(err)
-	body := []byte(fmt.Sprintf(`
-		{
-			"success": false,
-			"errorCode": %d,
-			"errorMessage": "%s"
-		}`,
-		statusCode,
-		http.StatusText(statusCode)))
-	return context.JSONBlob(statusCode, body)
+	handleError(context, err)
+
+	response := newErrorResponse(statusCode, err)
+	return context.JSON(response.Status, response)
 }
 
 // getUser helper
@@ -62,10 +53,43 @@ func getRefreshTokenClaims(context echo.Context) *models.JwtRefreshTokenClaims {
 	return claims.(*models.JwtRefreshTokenClaims)
 }
 
-func handleError(err error) {
-	log.Println(err.Error())
+// LogFields returns the fields that identify a request in structured logs
+func LogFields(context echo.Context) gommonLog.JSON {
+	fields := gommonLog.JSON{
+		"request_id": context.Response().Header().Get(echo.HeaderXRequestID),
+		"method":     context.Request().Method,
+		"route":      context.Path(),
+	}
+
+	// Both access and refresh tokens are stored under the same key
+	if token, ok := context.Get("user").(*jwt.Token); ok {
+		switch claims := token.Claims.(type) {
+		case *models.JwtClaims:
+			if claims.User != nil {
+				fields["user_id"] = claims.User.ID
+			}
+		case *models.JwtRefreshTokenClaims:
+			fields["user_id"] = claims.UserID
+		}
+	}
+
+	return fields
+}
+
+func handleError(context echo.Context, err error) {
+	fields := LogFields(context)
+	fields["error"] = err.Error()
 
 	if config.GetConfig().Debug {
-		debug.PrintStack()
+		fields["stack"] = string(debug.Stack())
 	}
+
+	context.Logger().Errorj(fields)
+}
+
+func logWarning(context echo.Context, message string) {
+	fields := LogFields(context)
+	fields["message"] = message
+
+	context.Logger().Warnj(fields)
 }
@@ -0,0 +1,34 @@
+package controllers_test
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/models"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// apiRequest calls a handler as the user a token belongs to, id is passed as the `id` path parameter when it's set
+func apiRequest(t *testing.T, token string, method string, path string, id uint64, body io.Reader, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(method, path, body)
+	if body != nil {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if id != 0 {
+		c.SetParamNames("id")
+		c.SetParamValues(fmt.Sprintf("%d", id))
+	}
+
+	assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(handler)(c))
+
+	return rec
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// Headers used to make retried requests safe
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotency-Replayed"
+)
+
+// replayedHeaders are the headers of a response that are stored along with its body so retries get them as well
+var replayedHeaders = []string{echo.HeaderLocation, "ETag"}
+
+// responseRecorder keeps a copy of everything written to the response
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (responseRecorder *responseRecorder) Write(data []byte) (int, error) {
+	responseRecorder.body.Write(data)
+	return responseRecorder.ResponseWriter.Write(data)
+}
+
+// Idempotent makes mutating requests that carry an Idempotency-Key header safe to retry,
+// the response to the first request is stored and replayed for every retry with the same key.
+// Keys are scoped per user so it has to run after authentication.
+func Idempotent(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(context echo.Context) error {
+		request := context.Request()
+		key := request.Header.Get(HeaderIdempotencyKey)
+
+		if key == "" || request.Method == echo.GET || request.Method == echo.HEAD || request.Method == echo.OPTIONS {
+			return next(context)
+		}
+		if len(key) > models.MaxIdempotencyKeyLength {
+			return ServeWithError(context, 400, withCode(ErrorCodeInvalidIdempotencyKey, fmt.Errorf("`%s` can be at most %d characters", HeaderIdempotencyKey, models.MaxIdempotencyKeyLength)))
+		}
+
+		user := getUser(context)
+		if user == nil {
+			return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+		}
+
+		// Hash the body so a key can't be reused for a different request
+		body, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+		}
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+
+		idempotencyKey := &models.IdempotencyKey{
+			UserID:      user.ID,
+			Key:         key,
+			Method:      request.Method,
+			Path:        request.URL.Path,
+			RequestHash: hex.EncodeToString(hash[:]),
+		}
+
+		idempotencyKeyCollection := models.IdempotencyKeyCollection{}
+		existingKey, err := idempotencyKeyCollection.Reserve(idempotencyKey, config.GetConfig().GetIdempotencyKeyTTL())
+		if err != nil {
+			return ServeWithError(context, 500, err)
+		}
+		if existingKey != nil {
+			return replayIdempotencyKey(context, idempotencyKey, existingKey)
+		}
+
+		// Handle the request while recording the response
+		recorder := &responseRecorder{ResponseWriter: context.Response().Writer}
+		context.Response().Writer = recorder
+		err = next(context)
+		context.Response().Writer = recorder.ResponseWriter
+
+		// Failed requests didn't change anything, so they can be retried with the same key
+		status := context.Response().Status
+		if err != nil || !context.Response().Committed || status >= http.StatusInternalServerError {
+			if releaseErr := idempotencyKeyCollection.Release(idempotencyKey); releaseErr != nil {
+				handleError(context, releaseErr)
+			}
+			return err
+		}
+
+		contentType := context.Response().Header().Get(echo.HeaderContentType)
+		idempotencyKey.StatusCode = &status
+		idempotencyKey.ContentType = &contentType
+		idempotencyKey.ResponseBody = recorder.body.Bytes()
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := context.Response().Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		idempotencyKey.ResponseHeaders, err = json.Marshal(headers)
+		if err != nil {
+			handleError(context, err)
+		}
+
+		if err := idempotencyKeyCollection.Complete(idempotencyKey); err != nil {
+			handleError(context, err)
+		}
+
+		return nil
+	}
+}
+
+// replayIdempotencyKey serves the stored response of the request a key was first used for
+func replayIdempotencyKey(context echo.Context, idempotencyKey *models.IdempotencyKey, existingKey *models.IdempotencyKey) error {
+	if !existingKey.Matches(idempotencyKey) {
+		return ServeWithError(context, 422, withCode(ErrorCodeIdempotencyKeyReused, fmt.Errorf("`%s` was already used for a different request", HeaderIdempotencyKey)))
+	}
+	if !existingKey.IsCompleted() {
+		return ServeWithError(context, 409, withCode(ErrorCodeIdempotencyKeyInUse, fmt.Errorf("a request with this `%s` is still in progress", HeaderIdempotencyKey)))
+	}
+
+	contentType := echo.MIMEApplicationJSONCharsetUTF8
+	if existingKey.ContentType != nil && *existingKey.ContentType != "" {
+		contentType = *existingKey.ContentType
+	}
+
+	if len(existingKey.ResponseHeaders) > 0 {
+		headers := make(map[string]string)
+		if err := json.Unmarshal(existingKey.ResponseHeaders, &headers); err != nil {
+			handleError(context, err)
+		}
+		for name, value := range headers {
+			context.Response().Header().Set(name, value)
+		}
+	}
+
+	context.Response().Header().Set(HeaderIdempotencyReplayed, "true")
+	return context.Blob(*existingKey.StatusCode, contentType, existingKey.ResponseBody)
+}
@@ -0,0 +1,95 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/utils"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockIdempotencyJwtToken string
+var mockIdempotencyUser *models.User
+
+func init() {
+	utils.SetupTesting()
+	mockIdempotencyJwtToken, mockIdempotencyUser = utils.SetupTestUser("idempotency_test")
+}
+
+func postLogWithIdempotencyKey(t *testing.T, key string, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(echo.POST, "/api/logs", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockIdempotencyJwtToken))
+	req.Header.Set(controllers.HeaderIdempotencyKey, key)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs")
+
+	assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.Idempotent(controllers.APILogsPost))(c))
+	return rec
+}
+
+func TestIdempotentRetry(t *testing.T) {
+	logBody := `{
+    "language": "JA",
+    "date": "2017-06-01",
+    "duration": 45,
+    "activity": "LISTENING"
+  }`
+
+	// First request creates the log
+	rec := postLogWithIdempotencyKey(t, "retry-key", logBody)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "", rec.Header().Get(controllers.HeaderIdempotencyReplayed))
+	firstBody := rec.Body.String()
+	firstLocation := rec.Header().Get(echo.HeaderLocation)
+	firstETag := rec.Header().Get("ETag")
+
+	// Retry gets the same response without creating another log
+	rec = postLogWithIdempotencyKey(t, "retry-key", logBody)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(controllers.HeaderIdempotencyReplayed))
+	assert.Equal(t, firstBody, rec.Body.String())
+	assert.Equal(t, firstLocation, rec.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, firstETag, rec.Header().Get("ETag"))
+	assert.NotEmpty(t, firstLocation)
+
+	logCollection := models.LogCollection{}
+	assert.Nil(t, logCollection.GetAllFromUser(mockIdempotencyUser.ID))
+	assert.Equal(t, 1, logCollection.Length())
+
+	// Reusing the key for a different request fails
+	rec = postLogWithIdempotencyKey(t, "retry-key", strings.Replace(logBody, "45", "50", 1))
+	var body controllers.ErrorResponse
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, controllers.ErrorCodeIdempotencyKeyReused, body.Code)
+
+	// A different key creates a new log
+	rec = postLogWithIdempotencyKey(t, "other-key", logBody)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "", rec.Header().Get(controllers.HeaderIdempotencyReplayed))
+
+	logCollection = models.LogCollection{}
+	assert.Nil(t, logCollection.GetAllFromUser(mockIdempotencyUser.ID))
+	assert.Equal(t, 2, logCollection.Length())
+}
+
+func TestIdempotentInvalidKey(t *testing.T) {
+	rec := postLogWithIdempotencyKey(t, strings.Repeat("a", models.MaxIdempotencyKeyLength+1), `{}`)
+
+	var body controllers.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, controllers.ErrorCodeInvalidIdempotencyKey, body.Code)
+}
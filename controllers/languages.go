@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// APILanguagesGetAll gets all languages logs can be made for
+func APILanguagesGetAll(context echo.Context) error {
+	supportedLanguageCollection := models.SupportedLanguageCollection{Languages: make([]models.SupportedLanguage, 0)}
+
+	err := supportedLanguageCollection.GetAll()
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, supportedLanguageCollection)
+}
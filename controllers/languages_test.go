@@ -0,0 +1,91 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/antonve/logger-api/utils"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockLanguagesJwtToken string
+var mockLanguagesUser *models.User
+
+func init() {
+	utils.SetupTesting()
+	mockLanguagesJwtToken, mockLanguagesUser = utils.SetupTestUser("languages_test")
+}
+
+func TestLanguagesGetAll(t *testing.T) {
+	// Setup languages request, no token is needed
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/languages", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, controllers.APILanguagesGetAll(c)) {
+		var body models.SupportedLanguageCollection
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		codes := make(map[enums.Language]string)
+		for _, language := range body.Languages {
+			codes[language.Code] = language.Name
+		}
+		assert.Equal(t, "Japanese", codes[enums.LanguageJapanese])
+		assert.Equal(t, "Korean", codes[enums.LanguageKorean])
+		assert.Equal(t, "Spanish", codes["es"])
+		assert.Equal(t, "Vietnamese", codes["vi"])
+	}
+}
+
+func TestLogPostWithLanguageCode(t *testing.T) {
+	languages := []struct {
+		language string
+		status   int
+		expected enums.Language
+	}{
+		{"es", http.StatusCreated, "es"},
+		{"FR", http.StatusCreated, "fr"},
+		{"KR", http.StatusCreated, enums.LanguageKorean},
+		{"xx", http.StatusBadRequest, ""},
+		{"japanese", http.StatusBadRequest, ""},
+	}
+
+	for _, language := range languages {
+		// Setup create log request
+		e := echo.New()
+		logBody := strings.NewReader(fmt.Sprintf(`{
+    "language": "%s",
+    "date": "2017-05-01",
+    "duration": 30,
+    "activity": "READING"
+  }`, language.language))
+		req := httptest.NewRequest(echo.POST, "/api/logs", logBody)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLanguagesJwtToken))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+			assert.Equal(t, language.status, rec.Code, "language `%s`", language.language)
+
+			if language.status == http.StatusCreated {
+				var body models.Log
+				assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, language.expected, body.Language)
+				assert.Equal(t, mockLanguagesUser.ID, body.UserID)
+			}
+		}
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/antonve/logger-api/models"
-	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/antonve/logger-api/models/enums"
 
 	"github.com/labstack/echo"
 )
@@ -18,7 +19,7 @@ func APILogsPost(context echo.Context) error {
 	// Attempt to bind request to Log struct
 	err := context.Bind(log)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
 	}
 
 	user := getUser(context)
@@ -35,48 +36,124 @@ func APILogsPost(context echo.Context) error {
 
 	// Save to database
 	logCollection := models.LogCollection{}
-	_, err = logCollection.Add(log)
+	id, err := logCollection.Add(log, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	// Send back the log as it was stored so clients learn its id
+	log, err = logCollection.Get(id)
 	if err != nil {
 		return ServeWithError(context, 500, err)
 	}
 
-	return Serve(context, 201)
+	context.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/logs/%d", log.ID))
+	context.Response().Header().Set("ETag", formatETag(log.Version))
+
+	return context.JSON(http.StatusCreated, log)
 }
 
-// APILogsGetAll gets all logs
+// APILogsGetAll gets a page of logs
 func APILogsGetAll(context echo.Context) error {
-	logCollection := models.LogCollection{Logs: make([]models.Log, 0)}
+	logPage := models.LogPage{}
 	user := getUser(context)
 	if user == nil {
 		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
 	}
 
-	// Filters
-	filters := map[string]interface{}{
-		"user_id":  user.ID,
-		"date":     context.QueryParam("date"),
-		"from":     context.QueryParam("from"),
-		"until":    context.QueryParam("until"),
-		"language": context.QueryParam("language"),
-		"page":     context.QueryParam("page"),
+	filters, err := parseLogFilters(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
 	}
+	filters.UserID = user.ID
 
-	err := logCollection.GetAllWithFilters(filters)
+	limit, err := parseLimit(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
 
+	err = logPage.GetAllWithFilters(filters, context.QueryParam("cursor"), limit)
+	if err == models.ErrInvalidCursor {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidCursor, err))
+	}
 	if err != nil {
 		return ServeWithError(context, 500, err)
 	}
 
-	return context.JSON(http.StatusOK, logCollection)
+	return context.JSON(http.StatusOK, logPage)
+}
+
+// parseLogFilters reads the log filters from the query, multi-valued filters can be repeated or comma separated
+func parseLogFilters(context echo.Context) (*models.LogFilters, error) {
+	query := context.QueryParams()
+	filters := &models.LogFilters{
+		Date:  query.Get("date"),
+		From:  query.Get("from"),
+		Until: query.Get("until"),
+		Notes: make(map[string]string),
+		Sort:  splitQueryParam(query["sort"]),
+		Tags:  splitQueryParam(query["tag"]),
+	}
+
+	for _, language := range splitQueryParam(query["language"]) {
+		filters.Languages = append(filters.Languages, enums.ParseLanguage(language))
+	}
+	for _, activity := range splitQueryParam(query["activity"]) {
+		filters.Activities = append(filters.Activities, enums.Activity(strings.ToUpper(activity)))
+	}
+
+	validationErrors := models.ValidationErrors{}
+	numbers := []struct {
+		field  string
+		number *uint64
+	}{
+		{"min_duration", &filters.MinDuration},
+		{"max_duration", &filters.MaxDuration},
+		{"resource_id", &filters.ResourceID},
+		{"custom_activity_id", &filters.CustomActivityID},
+	}
+	for _, number := range numbers {
+		if query.Get(number.field) == "" {
+			continue
+		}
+
+		value, err := strconv.ParseUint(query.Get(number.field), 10, 64)
+		if err != nil {
+			validationErrors.Add(number.field, fmt.Sprintf("invalid `%s` supplied", number.field))
+		}
+		*number.number = value
+	}
+
+	for name := range query {
+		if strings.HasPrefix(name, "notes.") {
+			filters.Notes[strings.TrimPrefix(name, "notes.")] = query.Get(name)
+		}
+	}
+
+	return filters, validationErrors.Err()
+}
+
+// splitQueryParam splits the values of a repeated and/or comma separated query parameter
+func splitQueryParam(values []string) []string {
+	result := make([]string, 0)
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+
+	return result
 }
 
 // APILogsGetByID get a single log
 func APILogsGetByID(context echo.Context) error {
 	logCollection := models.LogCollection{}
 
-	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	id, err := parseID(context)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, err)
 	}
 
 	log, err := logCollection.Get(id)
@@ -85,14 +162,21 @@ func APILogsGetByID(context echo.Context) error {
 	}
 
 	if log == nil {
-		return ServeWithError(context, 404, fmt.Errorf("no log found with id %v", id))
+		return ServeWithError(context, 404, &models.NotFoundError{Resource: "log", ID: id})
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
 	}
 
-	user := context.Get("user").(*jwt.Token).Claims.(*models.JwtClaims).User
 	if !log.IsOwner(user.ID) {
 		return ServeWithError(context, 403, fmt.Errorf("log doesn't belong to user"))
 	}
 
+	// Clients send the ETag back in If-Match when updating the log
+	context.Response().Header().Set("ETag", formatETag(log.Version))
+
 	return context.JSON(http.StatusOK, log)
 }
 
@@ -103,13 +187,13 @@ func APILogsUpdate(context echo.Context) error {
 	// Attempt to bind request to Log struct
 	err := context.Bind(log)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
 	}
 
 	// Parse out id
-	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	id, err := parseID(context)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, err)
 	}
 
 	// Update
@@ -131,12 +215,34 @@ func APILogsUpdate(context echo.Context) error {
 	log.ID = currentLog.ID
 	log.UserID = currentLog.UserID
 
-	err = logCollection.Update(log)
+	// If-Match takes precedence over the version in the body
+	version, err := parseIfMatch(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+	if version != 0 {
+		log.Version = version
+	}
+
+	// Validate request
+	err = log.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	err = logCollection.Update(log, user.ID)
 	if err != nil {
 		return ServeWithError(context, 500, err)
 	}
 
-	return Serve(context, 200)
+	log, err = logCollection.Get(id)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	context.Response().Header().Set("ETag", formatETag(log.Version))
+
+	return context.JSON(http.StatusOK, log)
 }
 
 // APILogsDelete delete a log
@@ -144,9 +250,9 @@ func APILogsDelete(context echo.Context) error {
 	log := &models.Log{}
 
 	// Parse out id
-	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	id, err := parseID(context)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, err)
 	}
 	log.ID = id
 
@@ -164,11 +270,230 @@ func APILogsDelete(context echo.Context) error {
 	if !currentLog.IsOwner(user.ID) {
 		return ServeWithError(context, 403, fmt.Errorf("log doesn't belong to user"))
 	}
+	log.UserID = user.ID
+
+	// Don't delete changes the client hasn't seen yet
+	log.Version, err = parseIfMatch(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	err = logCollection.Delete(log, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return Serve(context, 200)
+}
+
+// APILogsGetChanges gets everything that changed in the logs of a user since a cursor, used to sync offline clients
+func APILogsGetChanges(context echo.Context) error {
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	since, ok := models.ParseLogCursor(context.QueryParam("since"))
+	if !ok {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidCursor, fmt.Errorf("invalid cursor `%s` supplied", context.QueryParam("since"))))
+	}
+
+	limit, err := parseLimit(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	logChangeCollection := models.LogChangeCollection{}
+	err = logChangeCollection.GetFromUser(user.ID, since, limit)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, logChangeCollection)
+}
+
+// APILogsSearch searches the notes of the logs of a user
+func APILogsSearch(context echo.Context) error {
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	limit, err := parseLimit(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	logSearchCollection := models.LogSearchCollection{}
+	err = logSearchCollection.Search(user.ID, context.QueryParam("q"), limit)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, logSearchCollection)
+}
+
+// APILogsGetDuplicates gets the logs of a user that look like accidental double submissions
+func APILogsGetDuplicates(context echo.Context) error {
+	logDuplicateCollection := models.LogDuplicateCollection{Duplicates: make([]models.LogDuplicate, 0)}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	window := uint64(models.DefaultDuplicateWindow)
+	if context.QueryParam("window") != "" {
+		var err error
+		window, err = strconv.ParseUint(context.QueryParam("window"), 10, 64)
+		if err != nil {
+			validationErrors := models.ValidationErrors{}
+			validationErrors.Add("window", fmt.Sprintf("invalid `Window` supplied, must be between 1 and %d minutes", models.MaxDuplicateWindow))
+			return ServeWithError(context, 400, validationErrors)
+		}
+	}
 
-	err = logCollection.Delete(log)
+	err := logDuplicateCollection.GetFromUser(user.ID, window)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, logDuplicateCollection)
+}
+
+// APILogsGetTrash gets all deleted logs that can still be restored
+func APILogsGetTrash(context echo.Context) error {
+	logCollection := models.LogCollection{Logs: make([]models.Log, 0)}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := logCollection.GetTrashFromUser(user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, logCollection)
+}
+
+// APILogsRestore restores a deleted log
+func APILogsRestore(context echo.Context) error {
+	log := &models.Log{}
+
+	// Parse out id
+	id, err := parseID(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+	log.ID = id
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+	log.UserID = user.ID
+
+	// Only logs of the user in the trash can be restored
+	logCollection := models.LogCollection{}
+	err = logCollection.Restore(log, user.ID)
 	if err != nil {
 		return ServeWithError(context, 500, err)
 	}
 
 	return Serve(context, 200)
 }
+
+// APILogsGetHistory gets all changes made to a log
+func APILogsGetHistory(context echo.Context) error {
+	logHistoryCollection := models.LogHistoryCollection{History: make([]models.LogHistory, 0)}
+
+	// Parse out id
+	id, err := parseID(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err = logHistoryCollection.GetByLog(id, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, logHistoryCollection)
+}
+
+// LogBatchResult is the outcome of a single operation in a batch request
+type LogBatchResult struct {
+	models.LogOperationResult
+	Success bool           `json:"success"`
+	Error   *ErrorResponse `json:"error,omitempty"`
+}
+
+// LogBatchResponse is the body of a batch request
+type LogBatchResponse struct {
+	Success bool             `json:"success"`
+	Results []LogBatchResult `json:"results"`
+}
+
+// APILogsBatch creates, updates and deletes several logs at once, either all operations are saved or none are
+func APILogsBatch(context echo.Context) error {
+	body := &struct {
+		Operations []models.LogOperation `json:"operations"`
+	}{}
+
+	// Attempt to bind request to a list of operations
+	err := context.Bind(body)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	if len(body.Operations) == 0 || len(body.Operations) > models.MaxLogOperations {
+		validationErrors := models.ValidationErrors{}
+		validationErrors.Add("operations", fmt.Sprintf("between 1 and %d operations must be supplied", models.MaxLogOperations))
+		return ServeWithError(context, 400, validationErrors)
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	// Save to database
+	logCollection := models.LogCollection{}
+	results, batchErr := logCollection.Batch(user.ID, body.Operations)
+
+	// The status of the first failed operation is used for the whole batch
+	response := LogBatchResponse{Success: batchErr == nil, Results: make([]LogBatchResult, len(results))}
+	statusCode := http.StatusOK
+	for index, result := range results {
+		response.Results[index] = LogBatchResult{LogOperationResult: result, Success: result.Applied}
+
+		var errorResponse ErrorResponse
+		switch {
+		case result.Err != nil:
+			handleError(context, result.Err)
+			errorResponse = newErrorResponse(500, result.Err)
+			if statusCode == http.StatusOK {
+				statusCode = errorResponse.Status
+			}
+		case !result.Applied:
+			errorResponse = newErrorResponse(http.StatusFailedDependency, withCode(ErrorCodeNotApplied, fmt.Errorf("not applied because another operation failed")))
+		default:
+			continue
+		}
+
+		response.Results[index].Error = &errorResponse
+	}
+
+	// None of the operations failed but the batch couldn't be saved
+	if batchErr != nil && statusCode == http.StatusOK {
+		handleError(context, batchErr)
+		statusCode = newErrorResponse(500, batchErr).Status
+	}
+
+	return context.JSON(statusCode, response)
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/antonve/logger-api/config"
 	"github.com/antonve/logger-api/controllers"
@@ -53,8 +55,294 @@ func TestLogPost(t *testing.T) {
 	c := e.NewContext(req, rec)
 
 	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+		var body models.Log
 		assert.Equal(t, http.StatusCreated, rec.Code)
-		assert.Equal(t, `{"success": true}`, rec.Body.String())
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		// The created log is sent back
+		assert.NotEqual(t, uint64(0), body.ID)
+		assert.Equal(t, mockLogsUser.ID, body.UserID)
+		assert.Equal(t, "2017-05-23", body.Date)
+		assert.Equal(t, uint64(25), body.Duration)
+		assert.Equal(t, fmt.Sprintf("/api/logs/%d", body.ID), rec.Header().Get(echo.HeaderLocation))
+	}
+}
+
+func TestLogPostInvalid(t *testing.T) {
+	// Setup create log request without a duration
+	e := echo.New()
+	logBody := strings.NewReader(`{
+    "language": "JA",
+    "date": "2017-05-23",
+    "activity": "READING"
+  }`)
+	req := httptest.NewRequest(echo.POST, "/api/logs", logBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+		// Check response
+		var body controllers.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+
+		// Check if the failing field is reported
+		assert.Nil(t, err)
+		assert.False(t, body.Success)
+		assert.Equal(t, controllers.ErrorCodeValidationFailed, body.Code)
+		if assert.Len(t, body.Details, 1) {
+			assert.Equal(t, "duration", body.Details[0].Field)
+		}
+	}
+}
+
+func TestLogPostInvalidFields(t *testing.T) {
+	// Setup create log request where every field is wrong
+	e := echo.New()
+	logBody := strings.NewReader(`{
+    "language": "XX",
+    "date": "23-05-2017",
+    "duration": 5000,
+    "activity": "SLEEPING"
+  }`)
+	req := httptest.NewRequest(echo.POST, "/api/logs", logBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+		// Check response
+		var body controllers.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+		assert.Nil(t, err)
+
+		// Check if all failing fields are reported at once
+		fields := make([]string, 0)
+		for _, detail := range body.Details {
+			fields = append(fields, detail.Field)
+		}
+		assert.Equal(t, []string{"date", "duration", "language", "activity"}, fields)
+	}
+}
+
+func TestLogPostInvalidNotes(t *testing.T) {
+	cases := []struct {
+		activity string
+		notes    string
+		fields   []string
+	}{
+		{"READING", `{"type": "COMIC", "series": "キングダム"}`, []string{"notes.type"}},
+		{"READING", `{"type": "BOOK", "volume": "one"}`, []string{"notes.volume"}},
+		{"READING", `{"type": "BOOK", "deck": "Core 2k"}`, []string{"notes.deck"}},
+		{"READING", `{"type": "BOOK", "reviews": 20, "deck": "Core 2k"}`, []string{"notes.deck", "notes.reviews"}},
+		{"READING", `{"Series": "キングダム"}`, []string{"notes.Series"}},
+		{"LISTENING", `{"source": "NHK", "episode": -1}`, []string{"notes.episode"}},
+		{"FLASHCARDS", `"Core 2k"`, []string{"notes"}},
+		{"OTHER", fmt.Sprintf(`{"comment": "%s"}`, strings.Repeat("a", 2001)), []string{"notes.comment"}},
+	}
+
+	for _, testCase := range cases {
+		// Setup create log request
+		e := echo.New()
+		logBody := strings.NewReader(fmt.Sprintf(`{
+      "language": "JA",
+      "date": "2017-05-23",
+      "duration": 25,
+      "activity": "%s",
+      "notes": %s
+    }`, testCase.activity, testCase.notes))
+		req := httptest.NewRequest(echo.POST, "/api/logs", logBody)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+			// Check response
+			var body controllers.ErrorResponse
+			assert.Equal(t, http.StatusBadRequest, rec.Code, testCase.notes)
+			assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+			fields := make([]string, 0)
+			for _, detail := range body.Details {
+				fields = append(fields, detail.Field)
+			}
+			assert.Equal(t, testCase.fields, fields, testCase.notes)
+		}
+	}
+}
+
+func TestLogPostWithTimes(t *testing.T) {
+	// Setup a user in Tokyo, dates are derived in their timezone
+	token, user := utils.SetupTestUser("log_times_test")
+	user.Preferences.Timezone = "Asia/Tokyo"
+	userCollection := models.UserCollection{}
+	assert.Nil(t, userCollection.Update(user))
+
+	cases := []struct {
+		body      string
+		status    int
+		date      string
+		duration  uint64
+		startedAt string
+	}{
+		// 01:30 in Tokyo belongs to the next day, the duration is the time between start and end
+		{`{"language": "ja", "activity": "READING", "started_at": "2017-06-01T16:30:00Z", "ended_at": "2017-06-01T17:15:00Z"}`, http.StatusCreated, "2017-06-02", 45, "2017-06-01T16:30:00Z"},
+		{`{"language": "ja", "activity": "READING", "started_at": "2017-06-02T16:30:00+00:00", "ended_at": "2017-06-02T17:15:00Z", "duration": 30}`, http.StatusCreated, "2017-06-03", 30, "2017-06-02T16:30:00Z"},
+		// The date always follows the start
+		{`{"language": "ja", "activity": "READING", "started_at": "2017-06-04T01:30:00+09:00", "duration": 30, "date": "2017-06-03"}`, http.StatusCreated, "2017-06-04", 30, "2017-06-03T16:30:00Z"},
+		{`{"language": "ja", "activity": "READING", "started_at": "2017-06-05T16:30:00Z", "ended_at": "2017-06-05T17:15:00Z", "duration": 60}`, http.StatusBadRequest, "", 0, ""},
+		{`{"language": "ja", "activity": "READING", "started_at": "2017-06-05T16:30:00Z", "ended_at": "2017-06-05T16:00:00Z"}`, http.StatusBadRequest, "", 0, ""},
+		{`{"language": "ja", "activity": "READING", "ended_at": "2017-06-05T17:15:00Z", "duration": 30, "date": "2017-06-05"}`, http.StatusBadRequest, "", 0, ""},
+		{`{"language": "ja", "activity": "READING", "started_at": "2017-06-05T16:30:00Z"}`, http.StatusBadRequest, "", 0, ""},
+	}
+
+	for _, testCase := range cases {
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/api/logs", strings.NewReader(testCase.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+			assert.Equal(t, testCase.status, rec.Code, testCase.body)
+
+			if testCase.status == http.StatusCreated {
+				var body models.Log
+				assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, testCase.date, body.Date)
+				assert.Equal(t, testCase.duration, body.Duration)
+				if assert.NotNil(t, body.StartedAt) {
+					assert.Equal(t, testCase.startedAt, body.StartedAt.UTC().Format(time.RFC3339))
+				}
+			}
+		}
+	}
+
+	// Moving the start to another day moves the log along, even when the old date is sent back
+	logCollection := models.LogCollection{}
+	startedAt := time.Date(2017, 6, 10, 10, 0, 0, 0, time.UTC)
+	id, err := logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Duration: 30, Activity: enums.ActivityReading, StartedAt: &startedAt}, user.ID)
+	assert.Nil(t, err)
+
+	e := echo.New()
+	req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/api/logs/%d", id), strings.NewReader(`{"language": "ja", "activity": "READING", "date": "2017-06-10", "started_at": "2017-06-11T10:00:00Z", "duration": 30}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", id))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsUpdate)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		log, err := logCollection.Get(id)
+		assert.Nil(t, err)
+		assert.Equal(t, "2017-06-11", log.Date)
+	}
+}
+
+func TestLogPostOverlap(t *testing.T) {
+	token, user := utils.SetupTestUser("log_overlap_test")
+	postLog := func(body string) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/api/logs", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c))
+		return rec
+	}
+
+	rec := postLog(`{"language": "ja", "activity": "READING", "started_at": "2017-08-01T10:00:00Z", "ended_at": "2017-08-01T11:00:00Z"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var first models.Log
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &first))
+
+	// Sessions can't overlap, logs without an end time last their duration
+	for _, body := range []string{
+		`{"language": "ja", "activity": "LISTENING", "started_at": "2017-08-01T10:30:00Z", "ended_at": "2017-08-01T11:30:00Z"}`,
+		`{"language": "ja", "activity": "LISTENING", "started_at": "2017-08-01T09:45:00Z", "duration": 30}`,
+	} {
+		rec = postLog(body)
+		var errorBody controllers.ErrorResponse
+		assert.Equal(t, http.StatusConflict, rec.Code, body)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &errorBody))
+		assert.Equal(t, controllers.ErrorCodeLogOverlap, errorBody.Code)
+	}
+
+	// Sessions right after each other and logs without a start time are fine
+	rec = postLog(`{"language": "ja", "activity": "LISTENING", "started_at": "2017-08-01T11:00:00Z", "duration": 30}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = postLog(`{"language": "ja", "activity": "LISTENING", "date": "2017-08-01", "duration": 30}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// A deleted log doesn't overlap, but can't be restored while another log takes its place
+	logCollection := models.LogCollection{}
+	assert.Nil(t, logCollection.Delete(&models.Log{ID: first.ID, UserID: user.ID}, user.ID))
+	rec = postLog(`{"language": "ja", "activity": "GRAMMAR", "started_at": "2017-08-01T10:00:00Z", "duration": 15}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	_, isOverlap := logCollection.Restore(&models.Log{ID: first.ID, UserID: user.ID}, user.ID).(*models.LogOverlapError)
+	assert.True(t, isOverlap)
+}
+
+func TestLogGetDuplicates(t *testing.T) {
+	token, user := utils.SetupTestUser("log_duplicates_test")
+
+	// Setup a log submitted twice, and a log that only differs in duration
+	logCollection := models.LogCollection{}
+	originalID, _ := logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Date: "2017-09-01", Duration: 30, Activity: enums.ActivityReading}, user.ID)
+	duplicateID, _ := logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Date: "2017-09-01", Duration: 30, Activity: enums.ActivityReading}, user.ID)
+	logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Date: "2017-09-01", Duration: 45, Activity: enums.ActivityReading}, user.ID)
+
+	getDuplicates := func(query string) (*httptest.ResponseRecorder, models.LogDuplicateCollection) {
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/api/logs/duplicates?"+query, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var body models.LogDuplicateCollection
+		if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetDuplicates)(c)) && rec.Code == http.StatusOK {
+			assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		}
+
+		return rec, body
+	}
+
+	rec, body := getDuplicates("")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, uint64(models.DefaultDuplicateWindow), body.Window)
+	if assert.Len(t, body.Duplicates, 1) {
+		assert.Equal(t, duplicateID, body.Duplicates[0].ID)
+		assert.Equal(t, originalID, body.Duplicates[0].DuplicateOf)
+	}
+
+	// Deleting the duplicate resolves it
+	assert.Nil(t, logCollection.Delete(&models.Log{ID: duplicateID, UserID: user.ID}, user.ID))
+	rec, body = getDuplicates("window=5")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, body.Duplicates, 0)
+
+	// Windows that aren't a number are reported the same way as windows that are out of range
+	for _, window := range []string{"0", "abc"} {
+		rec, _ = getDuplicates("window=" + window)
+		var errorBody controllers.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &errorBody))
+		assert.Equal(t, controllers.ErrorCodeValidationFailed, errorBody.Code)
+		if assert.Len(t, errorBody.Details, 1) {
+			assert.Equal(t, "window", errorBody.Details[0].Field)
+		}
 	}
 }
 
@@ -62,7 +350,7 @@ func TestLogGetByID(t *testing.T) {
 	// Setup log to grab
 	log := models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageKorean, Date: "2016-10-05", Duration: 60, Activity: enums.ActivityListening}
 	logCollection := models.LogCollection{}
-	id, _ := logCollection.Add(&log)
+	id, _ := logCollection.Add(&log, log.UserID)
 
 	// Setup log request
 	e := echo.New()
@@ -97,13 +385,62 @@ func TestLogGetByID(t *testing.T) {
 	}
 }
 
+func TestLogGetByIDNotFound(t *testing.T) {
+	// Setup log request for a log that doesn't exist
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/logs/9000000", nil)
+
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("9000000")
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetByID)(c)) {
+		// Check response
+		var body controllers.ErrorResponse
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, body.Status)
+		assert.Equal(t, controllers.ErrorCodeLogNotFound, body.Code)
+	}
+}
+
+func TestLogGetByIDInvalidID(t *testing.T) {
+	// Setup log request with a malformed id
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/logs/abc", nil)
+
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("abc")
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetByID)(c)) {
+		// Check response
+		var body controllers.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+
+		assert.Nil(t, err)
+		assert.Equal(t, controllers.ErrorCodeInvalidID, body.Code)
+	}
+}
+
 func TestLogGetAll(t *testing.T) {
 	// Setup log to grab
 	logCollection := models.LogCollection{}
 	var ids [3]uint64
-	ids[0], _ = logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-04-04", Duration: 30, Activity: enums.ActivityGrammar})
-	ids[1], _ = logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageMandarin, Date: "2016-04-03", Duration: 45, Activity: enums.ActivityOther})
-	ids[2], _ = logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageKorean, Date: "2016-04-05", Duration: 55, Activity: enums.ActivityTextbook})
+	ids[0], _ = logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-04-04", Duration: 30, Activity: enums.ActivityGrammar}, mockLogsUser.ID)
+	ids[1], _ = logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageMandarin, Date: "2016-04-03", Duration: 45, Activity: enums.ActivityOther}, mockLogsUser.ID)
+	ids[2], _ = logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageKorean, Date: "2016-04-05", Duration: 55, Activity: enums.ActivityTextbook}, mockLogsUser.ID)
 
 	// Setup log request
 	e := echo.New()
@@ -191,19 +528,9 @@ func TestLogGetAll(t *testing.T) {
 	}
 }
 
-func TestLogGetAllPagination(t *testing.T) {
-	// Setup log to grab
-	logCollection := models.LogCollection{}
-	var ids [35]uint64
-	for key := 0; key < 31; key++ {
-		ids[key], _ = logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: fmt.Sprintf("2016-07-%d", key+1), Duration: 30, Activity: enums.ActivityGrammar})
-	}
-
-	// Setup log request
+func getLogPage(t *testing.T, query string) (*httptest.ResponseRecorder, models.LogPage) {
 	e := echo.New()
-
-	// Page 1
-	req := httptest.NewRequest(echo.GET, "/api/logs?page=1", nil)
+	req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/logs?%s", query), nil)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
 
@@ -211,81 +538,122 @@ func TestLogGetAllPagination(t *testing.T) {
 	c := e.NewContext(req, rec)
 	c.SetPath("/api/logs")
 
-	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetAll)(c)) {
-		// Check response
-		var body LogsBody
-		assert.Equal(t, http.StatusOK, rec.Code)
-		err := json.Unmarshal(rec.Body.Bytes(), &body)
-
-		// Check if the log has information
-		assert.Nil(t, err)
+	var body models.LogPage
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetAll)(c)) && rec.Code == http.StatusOK {
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	}
 
-		countDays := make(map[string]bool)
+	return rec, body
+}
 
-		for _, log := range body.Logs {
-			countDays[log.Date] = true
-		}
-		assert.True(t, len(countDays) == 30)
+func TestLogGetAllPagination(t *testing.T) {
+	// Setup log to grab
+	logCollection := models.LogCollection{}
+	var ids [35]uint64
+	for key := 0; key < 31; key++ {
+		ids[key], _ = logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: fmt.Sprintf("2016-07-%02d", key+1), Duration: 30, Activity: enums.ActivityGrammar}, mockLogsUser.ID)
 	}
 
-	// Page 2
-	req = httptest.NewRequest(echo.GET, "/api/logs?page=2", nil)
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
-
-	rec = httptest.NewRecorder()
-	c = e.NewContext(req, rec)
-	c.SetPath("/api/logs")
+	// First page
+	rec, page := getLogPage(t, "from=2016-07-01&until=2016-07-31&limit=20")
+	assert.Equal(t, http.StatusOK, rec.Code)
 
-	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetAll)(c)) {
-		// Check response
-		var body LogsBody
-		assert.Equal(t, http.StatusOK, rec.Code)
-		err := json.Unmarshal(rec.Body.Bytes(), &body)
+	seenDays := make(map[string]bool)
+	for _, log := range page.Logs {
+		seenDays[log.Date] = true
+	}
+	assert.Len(t, seenDays, 20)
+	assert.True(t, seenDays["2016-07-31"])
+	assert.Equal(t, uint64(31), page.TotalLogs)
+	assert.Equal(t, uint64(31), page.TotalDates)
+	if !assert.NotNil(t, page.NextCursor) {
+		return
+	}
 
-		// Check if the log has information
-		assert.Nil(t, err)
+	// Logs added in the meantime don't shift the next page
+	logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageGerman, Date: "2016-07-31", Duration: 10, Activity: enums.ActivityOther}, mockLogsUser.ID)
 
-		countDays := make(map[string]bool)
+	// Last page
+	rec, page = getLogPage(t, fmt.Sprintf("from=2016-07-01&until=2016-07-31&limit=20&cursor=%s", *page.NextCursor))
+	assert.Equal(t, http.StatusOK, rec.Code)
 
-		for _, log := range body.Logs {
-			countDays[log.Date] = true
-		}
-		assert.True(t, len(countDays) < 30)
-		assert.False(t, len(countDays) == 0)
+	lastPageDays := make(map[string]bool)
+	for _, log := range page.Logs {
+		assert.False(t, seenDays[log.Date], "date %s is on both pages", log.Date)
+		lastPageDays[log.Date] = true
 	}
+	assert.Len(t, lastPageDays, 11)
+	assert.Equal(t, uint64(32), page.TotalLogs)
+	assert.Nil(t, page.NextCursor)
 
-	// Page 9000
-	req = httptest.NewRequest(echo.GET, "/api/logs?page=9000", nil)
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	// Without limit
+	rec, page = getLogPage(t, "from=2016-07-01&until=2016-07-31")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotNil(t, page.NextCursor)
 
-	rec = httptest.NewRecorder()
-	c = e.NewContext(req, rec)
-	c.SetPath("/api/logs")
+	// Limits above the maximum are capped instead of falling back to the default
+	rec, page = getLogPage(t, "from=2016-07-01&until=2016-07-31&limit=101")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, page.NextCursor)
 
-	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetAll)(c)) {
-		// Check response
-		var body LogsBody
-		assert.Equal(t, http.StatusOK, rec.Code)
-		err := json.Unmarshal(rec.Body.Bytes(), &body)
+	// Invalid cursor
+	rec, _ = getLogPage(t, "cursor=abc")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-		// Check if the log has information
-		assert.Nil(t, err)
+	// Invalid limit
+	rec, _ = getLogPage(t, "limit=-1")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestLogGetAllFilters(t *testing.T) {
+	// Setup logs on a single date
+	logCollection := models.LogCollection{}
+	logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-08-10", Duration: 20, Activity: enums.ActivityReading, Notes: []byte(`{"type": "BOOK", "series": "キングダム"}`)}, mockLogsUser.ID)
+	logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-08-10", Duration: 40, Activity: enums.ActivityListening}, mockLogsUser.ID)
+	logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageKorean, Date: "2016-08-10", Duration: 60, Activity: enums.ActivityGrammar}, mockLogsUser.ID)
 
-		countDays := make(map[string]bool)
+	cases := []struct {
+		query     string
+		durations []uint64
+	}{
+		{"activity=READING,LISTENING", []uint64{20, 40}},
+		{"activity=READING&activity=GRAMMAR", []uint64{20, 60}},
+		{"activity=reading,Listening", []uint64{20, 40}},
+		{"language=KR", []uint64{60}},
+		{"language=JA,KR&sort=-duration", []uint64{60, 40, 20}},
+		{"min_duration=30&max_duration=50", []uint64{40}},
+		{"notes.type=BOOK", []uint64{20}},
+		{"notes.type=BOOK&notes.series=" + url.QueryEscape("キングダム"), []uint64{20}},
+		{"notes.type=MANGA", []uint64{}},
+		{"sort=duration", []uint64{20, 40, 60}},
+	}
 
-		for _, log := range body.Logs {
-			countDays[log.Date] = true
+	for _, testCase := range cases {
+		rec, page := getLogPage(t, "date=2016-08-10&"+testCase.query)
+		assert.Equal(t, http.StatusOK, rec.Code, testCase.query)
+
+		durations := make([]uint64, 0)
+		for _, log := range page.Logs {
+			durations = append(durations, log.Duration)
 		}
-		assert.True(t, len(countDays) == 0)
+		assert.Equal(t, testCase.durations, durations, testCase.query)
+	}
+
+	// Invalid filters
+	for _, query := range []string{"activity=SWIMMING", "language=XX", "min_duration=abc", "min_duration=50&max_duration=10", "sort=notes", "sort=date,-date", "notes.bad-key=1", "from=yesterday"} {
+		rec, _ := getLogPage(t, query)
+
+		var body controllers.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, controllers.ErrorCodeValidationFailed, body.Code, query)
 	}
 }
 
 func TestLogUpdate(t *testing.T) {
 	// Setup log to grab
 	logCollection := models.LogCollection{}
-	id, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageGerman, Date: "2016-03-30", Duration: 5, Activity: enums.ActivityTranslation})
+	id, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageGerman, Date: "2016-03-30", Duration: 5, Activity: enums.ActivityTranslation}, mockLogsUser.ID)
 
 	// Setup log request
 	e := echo.New()
@@ -307,8 +675,12 @@ func TestLogUpdate(t *testing.T) {
 
 	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsUpdate)(c)) {
 		// Check response
+		var body models.Log
 		assert.Equal(t, http.StatusOK, rec.Code)
-		assert.Equal(t, `{"success": true}`, rec.Body.String())
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, id, body.ID)
+		assert.Equal(t, uint64(25), body.Duration)
+		assert.Equal(t, fmt.Sprintf(`"%d"`, body.Version), rec.Header().Get("ETag"))
 
 		log, _ := logCollection.Get(id)
 		assert.Equal(t, enums.LanguageKorean, log.Language)
@@ -321,7 +693,7 @@ func TestLogUpdate(t *testing.T) {
 func TestLogDelete(t *testing.T) {
 	// Setup log to grab
 	logCollection := models.LogCollection{}
-	id, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-01-30", Duration: 50, Activity: enums.ActivityFlashcards})
+	id, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-01-30", Duration: 50, Activity: enums.ActivityFlashcards}, mockLogsUser.ID)
 
 	// Setup log request
 	e := echo.New()
@@ -345,3 +717,457 @@ func TestLogDelete(t *testing.T) {
 		assert.Error(t, err)
 	}
 }
+
+func TestLogTrashAndRestore(t *testing.T) {
+	// Setup deleted log
+	logCollection := models.LogCollection{}
+	log := &models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageMandarin, Date: "2016-01-12", Duration: 15, Activity: enums.ActivityReading}
+	log.ID, _ = logCollection.Add(log, log.UserID)
+	assert.Nil(t, logCollection.Delete(log, log.UserID))
+
+	// Setup trash request
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/logs/trash", nil)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/trash")
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetTrash)(c)) {
+		// Check response
+		var body LogsBody
+		assert.Equal(t, http.StatusOK, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+		assert.Nil(t, err)
+
+		// Check if the deleted log is in the trash
+		found := false
+		for _, trashedLog := range body.Logs {
+			if trashedLog.ID == log.ID {
+				found = true
+				assert.NotNil(t, trashedLog.DeletedAt)
+			}
+		}
+		assert.True(t, found)
+	}
+
+	// Setup restore request
+	req = httptest.NewRequest(echo.POST, fmt.Sprintf("/api/logs/%d/restore", log.ID), nil)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetPath("/api/logs/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", log.ID))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsRestore)(c)) {
+		// Check response
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		restoredLog, err := logCollection.Get(log.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, log.ID, restoredLog.ID)
+	}
+
+	// Restoring a log that isn't in the trash fails
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetPath("/api/logs/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", log.ID))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsRestore)(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestLogPurgeDeleted(t *testing.T) {
+	// Setup deleted log
+	logCollection := models.LogCollection{}
+	log := &models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageGerman, Date: "2016-01-13", Duration: 10, Activity: enums.ActivityOther}
+	log.ID, _ = logCollection.Add(log, log.UserID)
+	assert.Nil(t, logCollection.Delete(log, log.UserID))
+
+	// Logs within the retention period are kept
+	_, err := logCollection.PurgeDeleted(time.Hour)
+	assert.Nil(t, err)
+	assert.Nil(t, logCollection.Restore(&models.Log{ID: log.ID, UserID: mockLogsUser.ID}, mockLogsUser.ID))
+	assert.Nil(t, logCollection.Delete(log, log.UserID))
+
+	// Logs past the retention period are gone for good
+	purged, err := logCollection.PurgeDeleted(0)
+	assert.Nil(t, err)
+	assert.True(t, purged >= 1)
+	assert.Error(t, logCollection.Restore(&models.Log{ID: log.ID, UserID: mockLogsUser.ID}, mockLogsUser.ID))
+}
+
+func TestLogGetHistory(t *testing.T) {
+	// Setup log with a few changes
+	logCollection := models.LogCollection{}
+	log := &models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-01-20", Duration: 10, Activity: enums.ActivityReading}
+	log.ID, _ = logCollection.Add(log, log.UserID)
+	log.Duration = 40
+	assert.Nil(t, logCollection.Update(log, log.UserID))
+	assert.Nil(t, logCollection.Delete(log, log.UserID))
+	assert.Nil(t, logCollection.Restore(log, log.UserID))
+
+	// Setup history request
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/logs/%d/history", log.ID), nil)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/:id/history")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", log.ID))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetHistory)(c)) {
+		// Check response
+		var body models.LogHistoryCollection
+		assert.Equal(t, http.StatusOK, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+		assert.Nil(t, err)
+
+		// Check if every change was recorded in order
+		actions := make([]enums.LogAction, 0)
+		for _, change := range body.History {
+			assert.Equal(t, log.ID, change.LogID)
+			assert.Equal(t, mockLogsUser.ID, change.UserID)
+			actions = append(actions, change.Action)
+		}
+		assert.Equal(t, []enums.LogAction{enums.LogActionCreate, enums.LogActionUpdate, enums.LogActionDelete, enums.LogActionRestore}, actions)
+
+		// Check if the update has the old and new values
+		if assert.Len(t, body.History, 4) {
+			var oldLog, newLog models.Log
+			assert.Nil(t, body.History[1].OldValues.Unmarshal(&oldLog))
+			assert.Nil(t, body.History[1].NewValues.Unmarshal(&newLog))
+			assert.Equal(t, uint64(10), oldLog.Duration)
+			assert.Equal(t, uint64(40), newLog.Duration)
+			assert.Nil(t, body.History[2].NewValues)
+		}
+	}
+}
+
+func TestLogBatch(t *testing.T) {
+	// Setup logs to update and delete
+	logCollection := models.LogCollection{}
+	updateID, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-02-10", Duration: 10, Activity: enums.ActivityReading}, mockLogsUser.ID)
+	deleteID, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-02-10", Duration: 20, Activity: enums.ActivityListening}, mockLogsUser.ID)
+
+	// Setup batch request
+	e := echo.New()
+	batchBody := strings.NewReader(fmt.Sprintf(`{
+    "operations": [
+      {"action": "CREATE", "log": {"language": "ZH", "date": "2016-02-11", "duration": 30, "activity": "GRAMMAR"}},
+      {"action": "UPDATE", "id": %d, "log": {"language": "JA", "date": "2016-02-10", "duration": 45, "activity": "READING"}},
+      {"action": "DELETE", "id": %d}
+    ]
+  }`, updateID, deleteID))
+	req := httptest.NewRequest(echo.POST, "/api/logs/batch", batchBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsBatch)(c)) {
+		// Check response
+		var body controllers.LogBatchResponse
+		assert.Equal(t, http.StatusOK, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+		assert.Nil(t, err)
+		assert.True(t, body.Success)
+
+		if assert.Len(t, body.Results, 3) {
+			for _, result := range body.Results {
+				assert.True(t, result.Success)
+			}
+
+			// Check if all operations were applied
+			createdLog, err := logCollection.Get(body.Results[0].ID)
+			assert.Nil(t, err)
+			assert.Equal(t, enums.LanguageMandarin, createdLog.Language)
+
+			updatedLog, err := logCollection.Get(updateID)
+			assert.Nil(t, err)
+			assert.Equal(t, uint64(45), updatedLog.Duration)
+
+			_, err = logCollection.Get(deleteID)
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestLogBatchRollback(t *testing.T) {
+	// Setup log to update
+	logCollection := models.LogCollection{}
+	updateID, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageGerman, Date: "2016-02-12", Duration: 10, Activity: enums.ActivityReading}, mockLogsUser.ID)
+
+	// Setup batch request where the last operation refers to a log that doesn't exist
+	e := echo.New()
+	batchBody := strings.NewReader(fmt.Sprintf(`{
+    "operations": [
+      {"action": "CREATE", "log": {"language": "DE", "date": "2016-02-12", "duration": 15, "activity": "LISTENING"}},
+      {"action": "UPDATE", "id": %d, "log": {"language": "DE", "date": "2016-02-12", "duration": 99, "activity": "READING"}},
+      {"action": "DELETE", "id": 9000000}
+    ]
+  }`, updateID))
+	req := httptest.NewRequest(echo.POST, "/api/logs/batch", batchBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsBatch)(c)) {
+		// Check response
+		var body controllers.LogBatchResponse
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+		assert.Nil(t, err)
+		assert.False(t, body.Success)
+
+		if assert.Len(t, body.Results, 3) {
+			assert.Equal(t, controllers.ErrorCodeNotApplied, body.Results[0].Error.Code)
+			assert.Equal(t, controllers.ErrorCodeNotApplied, body.Results[1].Error.Code)
+			assert.Equal(t, controllers.ErrorCodeLogNotFound, body.Results[2].Error.Code)
+
+			// The created log was rolled back, its id must not be handed out
+			assert.Equal(t, uint64(0), body.Results[0].ID)
+			assert.Nil(t, body.Results[0].Log)
+
+			// Operations on existing logs still say which log they were for
+			assert.Equal(t, updateID, body.Results[1].ID)
+			assert.Nil(t, body.Results[1].Log)
+			assert.Equal(t, uint64(9000000), body.Results[2].ID)
+		}
+
+		// Check if nothing was applied
+		log, err := logCollection.Get(updateID)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(10), log.Duration)
+	}
+}
+
+func TestLogUpdateVersionConflict(t *testing.T) {
+	// Setup log to update
+	logCollection := models.LogCollection{}
+	id, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageGerman, Date: "2016-04-02", Duration: 5, Activity: enums.ActivityGrammar}, mockLogsUser.ID)
+	log, _ := logCollection.Get(id)
+	assert.Equal(t, uint64(1), log.Version)
+
+	e := echo.New()
+	updateLog := func(ifMatch string) *httptest.ResponseRecorder {
+		logBody := strings.NewReader(`{
+      "language": "DE",
+      "date": "2016-04-02",
+      "duration": 10,
+      "activity": "GRAMMAR"
+    }`)
+		req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/api/logs/%d", id), logBody)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/logs/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(fmt.Sprintf("%d", id))
+
+		assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsUpdate)(c))
+		return rec
+	}
+
+	// Up to date version is accepted
+	rec := updateLog(`"1"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	log, _ = logCollection.Get(id)
+	assert.Equal(t, uint64(2), log.Version)
+	assert.Equal(t, uint64(10), log.Duration)
+
+	// Stale version is rejected
+	rec = updateLog(`"1"`)
+	var body controllers.ErrorResponse
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, controllers.ErrorCodeVersionConflict, body.Code)
+
+	log, _ = logCollection.Get(id)
+	assert.Equal(t, uint64(2), log.Version)
+
+	// Malformed version
+	rec = updateLog("abc")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	deleteLog := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.DELETE, fmt.Sprintf("/api/logs/%d", id), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/logs/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(fmt.Sprintf("%d", id))
+
+		assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsDelete)(c))
+		return rec
+	}
+
+	// Deletes don't overwrite changes the client hasn't seen
+	rec = deleteLog(`"1"`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	_, err := logCollection.Get(id)
+	assert.Nil(t, err)
+
+	rec = deleteLog(`"2"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func getLogChanges(t *testing.T, since string) models.LogChangeCollection {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/logs/changes?since=%s", since), nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/changes")
+
+	var body models.LogChangeCollection
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetChanges)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	}
+
+	return body
+}
+
+func TestLogGetChanges(t *testing.T) {
+	// Catch up on everything that happened before this test
+	changes := getLogChanges(t, "")
+	for changes.HasMore {
+		changes = getLogChanges(t, changes.NextCursor)
+	}
+	cursor := changes.NextCursor
+
+	// Nothing changed, the cursor stays the same
+	changes = getLogChanges(t, cursor)
+	assert.Len(t, changes.Changes, 0)
+	assert.Equal(t, cursor, changes.NextCursor)
+
+	// Create a log and delete it again
+	logCollection := models.LogCollection{}
+	log := &models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-04-03", Duration: 30, Activity: enums.ActivityListening}
+	log.ID, _ = logCollection.Add(log, log.UserID)
+
+	changes = getLogChanges(t, cursor)
+	if assert.Len(t, changes.Changes, 1) {
+		assert.Equal(t, log.ID, changes.Changes[0].ID)
+		assert.False(t, changes.Changes[0].Deleted)
+	}
+	cursor = changes.NextCursor
+
+	assert.Nil(t, logCollection.Delete(log, log.UserID))
+
+	// Deleted logs are sent as tombstones
+	changes = getLogChanges(t, cursor)
+	if assert.Len(t, changes.Changes, 1) {
+		assert.Equal(t, log.ID, changes.Changes[0].ID)
+		assert.True(t, changes.Changes[0].Deleted)
+		assert.Equal(t, uint64(2), changes.Changes[0].Version)
+	}
+
+	// Clients that sync after the log was purged still learn it's gone
+	_, err := logCollection.PurgeDeleted(0)
+	assert.Nil(t, err)
+
+	changes = getLogChanges(t, cursor)
+	assert.Len(t, changes.Changes, 0)
+	assert.Equal(t, []uint64{log.ID}, changes.PurgedIDs)
+
+	// Malformed cursor
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/logs/changes?since=abc", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/changes")
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetChanges)(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
+
+func searchLogs(t *testing.T, query string) (*httptest.ResponseRecorder, models.LogSearchCollection) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/logs/search?q=%s", url.QueryEscape(query)), nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockLogsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/logs/search")
+
+	var body models.LogSearchCollection
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsSearch)(c)) && rec.Code == http.StatusOK {
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	}
+
+	return rec, body
+}
+
+func TestLogSearch(t *testing.T) {
+	// Setup logs to search through
+	logCollection := models.LogCollection{}
+	kingdomID, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-09-01", Duration: 30, Activity: enums.ActivityReading, Notes: []byte(`{"type": "BOOK", "series": "キングダム", "volume": 7, "comment": "Finally read it"}`)}, mockLogsUser.ID)
+	onePieceID, _ := logCollection.Add(&models.Log{UserID: mockLogsUser.ID, Language: enums.LanguageJapanese, Date: "2016-09-02", Duration: 30, Activity: enums.ActivityReading, Notes: []byte(`{"type": "BOOK", "series": "ワンピース", "volume": 7}`)}, mockLogsUser.ID)
+
+	results := func(body models.LogSearchCollection) map[uint64]models.LogSearchResult {
+		found := make(map[uint64]models.LogSearchResult)
+		for _, result := range body.Results {
+			found[result.ID] = result
+		}
+		return found
+	}
+
+	// Full title
+	rec, body := searchLogs(t, "キングダム")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found := results(body)
+	if assert.Contains(t, found, kingdomID) {
+		assert.Equal(t, []string{"<mark>キングダム</mark>"}, found[kingdomID].Highlights)
+		assert.True(t, found[kingdomID].Rank > 0)
+	}
+	assert.NotContains(t, found, onePieceID)
+
+	// Part of a title along with a volume
+	rec, body = searchLogs(t, "グダム 7")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found = results(body)
+	assert.Contains(t, found, kingdomID)
+	assert.NotContains(t, found, onePieceID)
+
+	// Words still being typed
+	rec, body = searchLogs(t, "FINAL")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found = results(body)
+	if assert.Contains(t, found, kingdomID) {
+		assert.Equal(t, []string{"<mark>Final</mark>ly read it"}, found[kingdomID].Highlights)
+	}
+
+	// Both logs share the volume
+	rec, body = searchLogs(t, "7")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found = results(body)
+	assert.Contains(t, found, kingdomID)
+	assert.Contains(t, found, onePieceID)
+
+	// Deleted logs aren't found
+	assert.Nil(t, logCollection.Delete(&models.Log{ID: onePieceID, UserID: mockLogsUser.ID}, mockLogsUser.ID))
+	rec, body = searchLogs(t, "ワンピース")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, results(body), onePieceID)
+
+	// Nothing to search for
+	rec, _ = searchLogs(t, " !? ")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// APIResourcesGetAll gets all resources of the current user
+func APIResourcesGetAll(context echo.Context) error {
+	resourceCollection := models.ResourceCollection{Resources: make([]models.Resource, 0)}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := resourceCollection.GetAllFromUser(user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, resourceCollection)
+}
+
+// APIResourcesPost adds a resource
+func APIResourcesPost(context echo.Context) error {
+	resource := &models.Resource{}
+
+	// Attempt to bind request to Resource struct
+	err := context.Bind(resource)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+	resource.UserID = user.ID
+
+	// Validate request
+	err = resource.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	// Save to database
+	resourceCollection := models.ResourceCollection{}
+	id, err := resourceCollection.Add(resource)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	resource, err = resourceCollection.Get(id)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	context.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/resources/%d", resource.ID))
+
+	return context.JSON(http.StatusCreated, resource)
+}
+
+// APIResourcesGetByID gets a single resource
+func APIResourcesGetByID(context echo.Context) error {
+	resource, status, err := getOwnedResource(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	return context.JSON(http.StatusOK, resource)
+}
+
+// APIResourcesUpdate updates a resource
+func APIResourcesUpdate(context echo.Context) error {
+	resource := &models.Resource{}
+
+	// Attempt to bind request to Resource struct
+	err := context.Bind(resource)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	currentResource, status, err := getOwnedResource(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	resource.ID = currentResource.ID
+	resource.UserID = currentResource.UserID
+
+	// Validate request
+	err = resource.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	resourceCollection := models.ResourceCollection{}
+	err = resourceCollection.Update(resource)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	resource, err = resourceCollection.Get(resource.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, resource)
+}
+
+// APIResourcesDelete deletes a resource, logs referencing it are kept
+func APIResourcesDelete(context echo.Context) error {
+	resource, status, err := getOwnedResource(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	resourceCollection := models.ResourceCollection{}
+	err = resourceCollection.Delete(resource, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return Serve(context, 200)
+}
+
+// APIResourcesGetProgress gets how much of a resource the current user has read
+func APIResourcesGetProgress(context echo.Context) error {
+	resource, status, err := getOwnedResource(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	progress, err := resource.GetReadingProgress()
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, progress)
+}
+
+// getOwnedResource gets the resource in the `id` route parameter when it belongs to the current user,
+// otherwise returns the error along with the status to serve it with
+func getOwnedResource(context echo.Context) (*models.Resource, int, error) {
+	id, err := parseID(context)
+	if err != nil {
+		return nil, 400, err
+	}
+
+	resourceCollection := models.ResourceCollection{}
+	resource, err := resourceCollection.Get(id)
+	if err != nil {
+		return nil, 500, err
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return nil, 500, fmt.Errorf("could not receive user")
+	}
+
+	if !resource.IsOwner(user.ID) {
+		return nil, 403, fmt.Errorf("resource doesn't belong to user")
+	}
+
+	return resource, 0, nil
+}
@@ -0,0 +1,243 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/antonve/logger-api/utils"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockResourcesJwtToken string
+var mockResourcesUser *models.User
+
+func init() {
+	utils.SetupTesting()
+	mockResourcesJwtToken, mockResourcesUser = utils.SetupTestUser("resources_test")
+}
+
+func TestResourcePost(t *testing.T) {
+	// Setup create resource request
+	e := echo.New()
+	resourceBody := strings.NewReader(`{
+    "title": "キングダム",
+    "type": "BOOK",
+    "language": "JA",
+    "total_pages": 3000,
+    "external_url": "https://example.com/kingdom"
+  }`)
+	req := httptest.NewRequest(echo.POST, "/api/resources", resourceBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockResourcesJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIResourcesPost)(c)) {
+		var body models.Resource
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		assert.NotEqual(t, uint64(0), body.ID)
+		assert.Equal(t, mockResourcesUser.ID, body.UserID)
+		assert.Equal(t, "キングダム", body.Title)
+		if assert.NotNil(t, body.TotalPages) {
+			assert.Equal(t, uint64(3000), *body.TotalPages)
+		}
+		assert.Nil(t, body.TotalEpisodes)
+		assert.Equal(t, fmt.Sprintf("/api/resources/%d", body.ID), rec.Header().Get(echo.HeaderLocation))
+	}
+}
+
+func TestResourcePostInvalidFields(t *testing.T) {
+	// Setup create resource request where every field is wrong
+	e := echo.New()
+	resourceBody := strings.NewReader(`{
+    "type": "MOVIE",
+    "language": "XX",
+    "total_cards": 0,
+    "cover_url": "javascript:alert(1)"
+  }`)
+	req := httptest.NewRequest(echo.POST, "/api/resources", resourceBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockResourcesJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIResourcesPost)(c)) {
+		var body controllers.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		fields := make([]string, 0)
+		for _, detail := range body.Details {
+			fields = append(fields, detail.Field)
+		}
+		assert.Equal(t, []string{"title", "type", "language", "total_cards", "cover_url"}, fields)
+	}
+}
+
+func TestResourceUpdateAndGet(t *testing.T) {
+	// Setup resource to update
+	resourceCollection := models.ResourceCollection{}
+	id, err := resourceCollection.Add(&models.Resource{UserID: mockResourcesUser.ID, Title: "NHK News", Type: enums.ResourceTypePodcast, Language: enums.LanguageJapanese})
+	assert.Nil(t, err)
+
+	// Setup update request
+	e := echo.New()
+	resourceBody := strings.NewReader(`{
+    "title": "NHK News Easy",
+    "type": "PODCAST",
+    "language": "JA",
+    "total_episodes": 120
+  }`)
+	req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/api/resources/%d", id), resourceBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockResourcesJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/resources/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", id))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIResourcesUpdate)(c)) {
+		var body models.Resource
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "NHK News Easy", body.Title)
+	}
+
+	// Setup get request
+	req = httptest.NewRequest(echo.GET, fmt.Sprintf("/api/resources/%d", id), nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockResourcesJwtToken))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetPath("/api/resources/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", id))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIResourcesGetByID)(c)) {
+		var body models.Resource
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "NHK News Easy", body.Title)
+		if assert.NotNil(t, body.TotalEpisodes) {
+			assert.Equal(t, uint64(120), *body.TotalEpisodes)
+		}
+	}
+
+	// Resources of other users can't be accessed
+	otherID, _ := resourceCollection.Add(&models.Resource{UserID: mockLogsUser.ID, Title: "Core 2k", Type: enums.ResourceTypeDeck, Language: enums.LanguageJapanese})
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetPath("/api/resources/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", otherID))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIResourcesGetByID)(c)) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	}
+}
+
+func TestResourceLogs(t *testing.T) {
+	// Setup resource with a log
+	resourceCollection := models.ResourceCollection{}
+	resourceID, _ := resourceCollection.Add(&models.Resource{UserID: mockResourcesUser.ID, Title: "ワンピース", Type: enums.ResourceTypeBook, Language: enums.LanguageJapanese})
+
+	logCollection := models.LogCollection{}
+	logID, err := logCollection.Add(&models.Log{UserID: mockResourcesUser.ID, Language: enums.LanguageJapanese, Date: "2016-10-01", Duration: 30, Activity: enums.ActivityReading, ResourceID: &resourceID}, mockResourcesUser.ID)
+	assert.Nil(t, err)
+
+	log, _ := logCollection.Get(logID)
+	if assert.NotNil(t, log.ResourceID) {
+		assert.Equal(t, resourceID, *log.ResourceID)
+	}
+
+	// Logs can't reference resources of other users
+	otherResourceID, _ := resourceCollection.Add(&models.Resource{UserID: mockLogsUser.ID, Title: "ワンピース", Type: enums.ResourceTypeBook, Language: enums.LanguageJapanese})
+	_, err = logCollection.Add(&models.Log{UserID: mockResourcesUser.ID, Language: enums.LanguageJapanese, Date: "2016-10-01", Duration: 30, Activity: enums.ActivityReading, ResourceID: &otherResourceID}, mockResourcesUser.ID)
+	assert.IsType(t, models.ValidationErrors{}, err)
+
+	// Setup delete request
+	e := echo.New()
+	req := httptest.NewRequest(echo.DELETE, fmt.Sprintf("/api/resources/%d", resourceID), nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockResourcesJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/resources/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", resourceID))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIResourcesDelete)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// The log is kept without the resource
+		log, err := logCollection.Get(logID)
+		assert.Nil(t, err)
+		assert.Nil(t, log.ResourceID)
+		assert.Equal(t, uint64(2), log.Version)
+
+		// Unlinking the log is part of its history
+		logHistoryCollection := models.LogHistoryCollection{}
+		assert.Nil(t, logHistoryCollection.GetByLog(logID, mockResourcesUser.ID))
+		if assert.Equal(t, 2, logHistoryCollection.Length()) {
+			change := logHistoryCollection.History[1]
+			assert.Equal(t, enums.LogActionUpdate, change.Action)
+			assert.Equal(t, mockResourcesUser.ID, change.UserID)
+			assert.Contains(t, string(*change.OldValues), fmt.Sprintf(`"resource_id": %d`, resourceID))
+			assert.Contains(t, string(*change.NewValues), `"resource_id": null`)
+		}
+
+		_, err = resourceCollection.Get(resourceID)
+		assert.IsType(t, &models.NotFoundError{}, err)
+	}
+}
+
+func TestResourceGetProgress(t *testing.T) {
+	// Setup resource with a few reading sessions
+	resourceCollection := models.ResourceCollection{}
+	totalPages := uint64(200)
+	resourceID, _ := resourceCollection.Add(&models.Resource{UserID: mockResourcesUser.ID, Title: "よつばと！", Type: enums.ResourceTypeBook, Language: enums.LanguageJapanese, TotalPages: &totalPages})
+
+	logCollection := models.LogCollection{}
+	logCollection.Add(&models.Log{UserID: mockResourcesUser.ID, Language: enums.LanguageJapanese, Date: "2016-11-01", Duration: 30, Activity: enums.ActivityReading, ResourceID: &resourceID, Notes: []byte(`{"type": "BOOK", "pages": 20, "characters": 3000}`)}, mockResourcesUser.ID)
+	logCollection.Add(&models.Log{UserID: mockResourcesUser.ID, Language: enums.LanguageJapanese, Date: "2016-11-02", Duration: 20, Activity: enums.ActivityReading, ResourceID: &resourceID, Notes: []byte(`{"type": "BOOK", "pages": 30}`)}, mockResourcesUser.ID)
+
+	// Setup progress request
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/resources/%d/progress", resourceID), nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockResourcesJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/resources/:id/progress")
+	c.SetParamNames("id")
+	c.SetParamValues(fmt.Sprintf("%d", resourceID))
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIResourcesGetProgress)(c)) {
+		var body models.ReadingProgress
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		assert.Equal(t, resourceID, body.ResourceID)
+		assert.Equal(t, uint64(2), body.Sessions)
+		assert.Equal(t, uint64(50), body.Duration)
+		assert.Equal(t, uint64(50), body.Pages)
+		assert.Equal(t, uint64(3000), body.Characters)
+		if assert.NotNil(t, body.Completion) {
+			assert.Equal(t, 25.0, *body.Completion)
+		}
+		// Only the session in which characters were tracked counts towards the reading speed
+		if assert.NotNil(t, body.CharactersPerMinute) {
+			assert.Equal(t, 100.0, *body.CharactersPerMinute)
+		}
+	}
+}
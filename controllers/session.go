@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -29,7 +28,7 @@ func APISessionLogin(context echo.Context) error {
 	loginBody := &LoginBody{}
 	err := context.Bind(loginBody)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
 	}
 
 	// Get authentication data
@@ -104,7 +103,7 @@ func APISessionRefreshJWTToken(context echo.Context) error {
 	userCollection := models.UserCollection{Users: make([]models.User, 0)}
 	dbUser, err := userCollection.GetAuthenticationData(user.Email)
 	if err != nil {
-		log.Println(err)
+		logWarning(context, err.Error())
 		return echo.ErrUnauthorized
 	}
 
@@ -114,7 +113,7 @@ func APISessionRefreshJWTToken(context echo.Context) error {
 	if refreshTokenStringID != "" {
 		refreshTokenID, err := strconv.ParseUint(refreshTokenStringID, 10, 64)
 		if err != nil {
-			return ServeWithError(context, 500, err)
+			return ServeWithError(context, 400, withCode(ErrorCodeInvalidID, err))
 		}
 
 		// Only proceed when we have a valid id
@@ -128,7 +127,7 @@ func APISessionRefreshJWTToken(context echo.Context) error {
 
 			// Deny request if we have invalidated the refresh token
 			if refreshToken.InvalidatedAt.Valid {
-				log.Println("attempted JWT token refresh with expired session")
+				logWarning(context, "attempted JWT token refresh with expired session")
 				return echo.ErrUnauthorized
 			}
 		}
@@ -164,14 +163,14 @@ func APISessionAuthenticateWithRefreshToken(context echo.Context) error {
 
 	// We can't issue a JWT token when no valid token was found
 	if refreshToken == nil {
-		log.Println("attempted generating new JWT token with invalidated session")
+		logWarning(context, "attempted generating new JWT token with invalidated session")
 		return echo.ErrUnauthorized
 	}
 
 	// Check token contents
 	err = bcrypt.CompareHashAndPassword([]byte(refreshToken.RefreshToken), []byte(rawRefreshToken))
 	if err != nil {
-		log.Println(err)
+		logWarning(context, err.Error())
 		return echo.ErrUnauthorized
 	}
 
@@ -179,7 +178,7 @@ func APISessionAuthenticateWithRefreshToken(context echo.Context) error {
 	userCollection := models.UserCollection{Users: make([]models.User, 0)}
 	dbUser, err := userCollection.Get(refreshTokenClaims.UserID)
 	if err != nil {
-		log.Println(err)
+		logWarning(context, err.Error())
 		return echo.ErrUnauthorized
 	}
 
@@ -203,7 +202,7 @@ func APISessionRegister(context echo.Context) error {
 	// Attempt to bind request to User struct
 	err := context.Bind(user)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
 	}
 
 	user.HashPassword()
@@ -219,10 +218,18 @@ func APISessionRegister(context echo.Context) error {
 
 	// Save to database
 	userCollection := models.UserCollection{}
-	_, err = userCollection.Add(user)
+	id, err := userCollection.Add(user)
 	if err != nil {
 		return ServeWithError(context, 500, err)
 	}
 
-	return Serve(context, 201)
+	// Send back the user as it was stored, without the password
+	user, err = userCollection.Get(id)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	context.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/user/%d", user.ID))
+
+	return context.JSON(http.StatusCreated, user)
 }
@@ -48,8 +48,15 @@ func TestSessionCreateUser(t *testing.T) {
 	c := e.NewContext(req, rec)
 
 	if assert.NoError(t, controllers.APISessionRegister(c)) {
+		var body models.User
 		assert.Equal(t, http.StatusCreated, rec.Code)
-		assert.Equal(t, `{"success": true}`, rec.Body.String())
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		// The created user is sent back without the password
+		assert.NotEqual(t, uint64(0), body.ID)
+		assert.Equal(t, "register_test@example.com", body.Email)
+		assert.Equal(t, "", body.Password)
+		assert.Equal(t, fmt.Sprintf("/api/user/%d", body.ID), rec.Header().Get(echo.HeaderLocation))
 	}
 }
 
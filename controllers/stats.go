@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// APIStatsReading gets the reading speed of the current user per day, week or month
+func APIStatsReading(context echo.Context) error {
+	trend := models.ReadingSpeedTrend{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	filters, err := parseLogFilters(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+	filters.UserID = user.ID
+
+	err = trend.GetFromUser(filters, context.QueryParam("interval"))
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, trend)
+}
+
+// APIStatsPomodoros tallies the pomodoros of the current user per day
+func APIStatsPomodoros(context echo.Context) error {
+	tally := models.PomodoroTally{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := tally.GetFromUser(user.ID, context.QueryParam("from"), context.QueryParam("until"))
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, tally)
+}
+
+// APIStatsTags gets the time the current user spent per tag
+func APIStatsTags(context echo.Context) error {
+	tagDurationCollection := models.TagDurationCollection{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	filters, err := parseLogFilters(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+	filters.UserID = user.ID
+
+	err = tagDurationCollection.GetFromUser(filters)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, tagDurationCollection)
+}
+
+// APIStatsTimeOfDay gets the time the current user spent per hour of the day
+func APIStatsTimeOfDay(context echo.Context) error {
+	timeOfDayStats := models.TimeOfDayStats{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	filters, err := parseLogFilters(context)
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+	filters.UserID = user.ID
+
+	err = timeOfDayStats.GetFromUser(filters)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, timeOfDayStats)
+}
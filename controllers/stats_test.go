@@ -0,0 +1,119 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/antonve/logger-api/utils"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockStatsJwtToken string
+var mockStatsUser *models.User
+
+func init() {
+	utils.SetupTesting()
+	mockStatsJwtToken, mockStatsUser = utils.SetupTestUser("stats_test")
+}
+
+func getReadingStats(t *testing.T, query string) (*httptest.ResponseRecorder, models.ReadingSpeedTrend) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/stats/reading?"+query, nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockStatsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var body models.ReadingSpeedTrend
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIStatsReading)(c)) && rec.Code == http.StatusOK {
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	}
+
+	return rec, body
+}
+
+func TestStatsReading(t *testing.T) {
+	// Setup reading sessions over two months, other activities don't count.
+	// The amount read is taken from the notes, or given along with the log when the notes don't track it
+	pagesRead := uint64(15)
+	logCollection := models.LogCollection{}
+	logCollection.Add(&models.Log{UserID: mockStatsUser.ID, Language: enums.LanguageJapanese, Date: "2016-12-05", Duration: 30, Activity: enums.ActivityReading, Notes: []byte(`{"type": "BOOK", "pages": 10, "characters": 1500}`)}, mockStatsUser.ID)
+	logCollection.Add(&models.Log{UserID: mockStatsUser.ID, Language: enums.LanguageJapanese, Date: "2016-12-20", Duration: 30, Activity: enums.ActivityReading, Notes: []byte(`{"type": "BOOK", "pages": 10, "characters": 2100}`)}, mockStatsUser.ID)
+	logCollection.Add(&models.Log{UserID: mockStatsUser.ID, Language: enums.LanguageJapanese, Date: "2017-01-10", Duration: 60, Activity: enums.ActivityReading, Notes: []byte(`{"type": "NOVEL"}`), PagesRead: &pagesRead}, mockStatsUser.ID)
+	logCollection.Add(&models.Log{UserID: mockStatsUser.ID, Language: enums.LanguageJapanese, Date: "2016-12-06", Duration: 45, Activity: enums.ActivityListening}, mockStatsUser.ID)
+
+	rec, body := getReadingStats(t, "interval=month")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "month", body.Interval)
+	if assert.Len(t, body.Periods, 2) {
+		assert.Equal(t, "2016-12-01", body.Periods[0].Period)
+		assert.Equal(t, uint64(2), body.Periods[0].Sessions)
+		assert.Equal(t, uint64(20), body.Periods[0].Pages)
+		assert.Equal(t, uint64(3600), body.Periods[0].Characters)
+		if assert.NotNil(t, body.Periods[0].CharactersPerMinute) {
+			assert.Equal(t, 60.0, *body.Periods[0].CharactersPerMinute)
+		}
+
+		assert.Equal(t, "2017-01-01", body.Periods[1].Period)
+		assert.Equal(t, uint64(60), body.Periods[1].Duration)
+		assert.Equal(t, uint64(15), body.Periods[1].Pages)
+		assert.Nil(t, body.Periods[1].CharactersPerMinute)
+	}
+
+	// Weeks start on monday and filters narrow down the logs
+	rec, body = getReadingStats(t, "from=2016-12-01&until=2016-12-31")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, models.DefaultReadingStatsInterval, body.Interval)
+	if assert.Len(t, body.Periods, 2) {
+		assert.Equal(t, "2016-12-05", body.Periods[0].Period)
+		assert.Equal(t, "2016-12-19", body.Periods[1].Period)
+	}
+
+	rec, _ = getReadingStats(t, "interval=year")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestStatsTimeOfDay(t *testing.T) {
+	// Setup a user in Tokyo with sessions in the morning and evening there, logs without a start time don't count
+	token, user := utils.SetupTestUser("time_of_day_test")
+	user.Preferences.Timezone = "Asia/Tokyo"
+	userCollection := models.UserCollection{}
+	assert.Nil(t, userCollection.Update(user))
+
+	morning := time.Date(2017, 7, 1, 22, 0, 0, 0, time.UTC)
+	nextMorning := time.Date(2017, 7, 2, 22, 30, 0, 0, time.UTC)
+	evening := time.Date(2017, 7, 3, 12, 0, 0, 0, time.UTC)
+	logCollection := models.LogCollection{}
+	logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Duration: 30, Activity: enums.ActivityFlashcards, StartedAt: &morning}, user.ID)
+	logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Duration: 20, Activity: enums.ActivityFlashcards, StartedAt: &nextMorning}, user.ID)
+	logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Duration: 60, Activity: enums.ActivityReading, StartedAt: &evening}, user.ID)
+	logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Date: "2017-07-03", Duration: 45, Activity: enums.ActivityReading}, user.ID)
+
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/stats/time-of-day", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIStatsTimeOfDay)(c)) {
+		var body models.TimeOfDayStats
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		assert.Equal(t, "Asia/Tokyo", body.Timezone)
+		if assert.Len(t, body.Hours, 24) {
+			assert.Equal(t, models.TimeOfDayHour{Hour: 7, Logs: 2, Duration: 50}, body.Hours[7])
+			assert.Equal(t, models.TimeOfDayHour{Hour: 21, Logs: 1, Duration: 60}, body.Hours[21])
+			assert.Equal(t, models.TimeOfDayHour{Hour: 12, Logs: 0, Duration: 0}, body.Hours[12])
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// APITagsGetAll gets all tags of the current user
+func APITagsGetAll(context echo.Context) error {
+	tagCollection := models.TagCollection{Tags: make([]models.Tag, 0)}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := tagCollection.GetAllFromUser(user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, tagCollection)
+}
+
+// APITagsUpdate renames a tag
+func APITagsUpdate(context echo.Context) error {
+	tag := &models.Tag{}
+
+	// Attempt to bind request to Tag struct
+	err := context.Bind(tag)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	currentTag, status, err := getOwnedTag(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	tag.ID = currentTag.ID
+	tag.UserID = currentTag.UserID
+
+	// Validate request
+	err = tag.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	tagCollection := models.TagCollection{}
+	err = tagCollection.Update(tag, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	tag, err = tagCollection.Get(tag.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, tag)
+}
+
+// APITagsDelete deletes a tag, it's removed from all logs
+func APITagsDelete(context echo.Context) error {
+	tag, status, err := getOwnedTag(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	tagCollection := models.TagCollection{}
+	err = tagCollection.Delete(tag, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return Serve(context, 200)
+}
+
+// getOwnedTag gets the tag in the `id` route parameter when it belongs to the current user,
+// otherwise returns the error along with the status to serve it with
+func getOwnedTag(context echo.Context) (*models.Tag, int, error) {
+	id, err := parseID(context)
+	if err != nil {
+		return nil, 400, err
+	}
+
+	tagCollection := models.TagCollection{}
+	tag, err := tagCollection.Get(id)
+	if err != nil {
+		return nil, 500, err
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return nil, 500, fmt.Errorf("could not receive user")
+	}
+
+	if !tag.IsOwner(user.ID) {
+		return nil, 403, fmt.Errorf("tag doesn't belong to user")
+	}
+
+	return tag, 0, nil
+}
@@ -0,0 +1,161 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/antonve/logger-api/utils"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockTagsJwtToken string
+var mockTagsUser *models.User
+
+func init() {
+	utils.SetupTesting()
+	mockTagsJwtToken, mockTagsUser = utils.SetupTestUser("tags_test")
+}
+
+func TestLogPostWithTags(t *testing.T) {
+	// Setup create log request
+	e := echo.New()
+	logBody := strings.NewReader(`{
+    "language": "JA",
+    "date": "2017-03-01",
+    "duration": 45,
+    "activity": "GRAMMAR",
+    "tags": ["JLPT N2", "with tutor"]
+  }`)
+	req := httptest.NewRequest(echo.POST, "/api/logs", logBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockTagsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+		var body models.Log
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, []string{"JLPT N2", "with tutor"}, []string(body.Tags))
+	}
+
+	// Tags are compared regardless of case and can't be used twice on a log
+	logBody = strings.NewReader(`{
+    "language": "JA",
+    "date": "2017-03-01",
+    "duration": 45,
+    "activity": "GRAMMAR",
+    "tags": ["commute", "Commute", "a,b", ""]
+  }`)
+	req = httptest.NewRequest(echo.POST, "/api/logs", logBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockTagsJwtToken))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsPost)(c)) {
+		var body controllers.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Len(t, body.Details, 3)
+	}
+}
+
+func TestTagsFiltersAndStats(t *testing.T) {
+	// Setup tagged logs
+	logCollection := models.LogCollection{}
+	commuteID, _ := logCollection.Add(&models.Log{UserID: mockTagsUser.ID, Language: enums.LanguageJapanese, Date: "2017-04-01", Duration: 20, Activity: enums.ActivityListening, Tags: []string{"commute"}}, mockTagsUser.ID)
+	logCollection.Add(&models.Log{UserID: mockTagsUser.ID, Language: enums.LanguageJapanese, Date: "2017-04-02", Duration: 30, Activity: enums.ActivityListening, Tags: []string{"Commute", "podcast"}}, mockTagsUser.ID)
+	logCollection.Add(&models.Log{UserID: mockTagsUser.ID, Language: enums.LanguageJapanese, Date: "2017-04-03", Duration: 60, Activity: enums.ActivityReading}, mockTagsUser.ID)
+
+	// Updating a log without tags keeps its tags
+	log, _ := logCollection.Get(commuteID)
+	log.Tags = nil
+	log.Duration = 25
+	assert.Nil(t, logCollection.Update(log, log.UserID))
+	log, _ = logCollection.Get(commuteID)
+	assert.Equal(t, []string{"commute"}, []string(log.Tags))
+
+	// Filter logs by tag
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/api/logs?from=2017-04-01&until=2017-04-30&tag=COMMUTE", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockTagsJwtToken))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APILogsGetAll)(c)) {
+		var body models.LogPage
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, uint64(2), body.TotalLogs)
+	}
+
+	// Time spent per tag
+	req = httptest.NewRequest(echo.GET, "/api/stats/tags?from=2017-04-01&until=2017-04-30", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", mockTagsJwtToken))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+
+	if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIStatsTags)(c)) {
+		var body models.TagDurationCollection
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		if assert.Len(t, body.Tags, 2) {
+			assert.Equal(t, "commute", body.Tags[0].Name)
+			assert.Equal(t, uint64(2), body.Tags[0].Logs)
+			assert.Equal(t, uint64(55), body.Tags[0].Duration)
+			assert.Equal(t, "podcast", body.Tags[1].Name)
+			assert.Equal(t, uint64(30), body.Tags[1].Duration)
+		}
+	}
+}
+
+func TestTagUpdateAndDelete(t *testing.T) {
+	// Setup tagged log
+	logCollection := models.LogCollection{}
+	logID, _ := logCollection.Add(&models.Log{UserID: mockTagsUser.ID, Language: enums.LanguageJapanese, Date: "2017-05-01", Duration: 20, Activity: enums.ActivityFlashcards, Tags: []string{"anki", "morning"}}, mockTagsUser.ID)
+
+	tagCollection := models.TagCollection{}
+	assert.Nil(t, tagCollection.GetAllFromUser(mockTagsUser.ID))
+	tags := make(map[string]models.Tag)
+	for _, tag := range tagCollection.Tags {
+		tags[tag.Name] = tag
+	}
+
+	// Renaming to the name of another tag fails
+	rec := apiRequest(t, mockTagsJwtToken, echo.PUT, fmt.Sprintf("/api/tags/%d", tags["anki"].ID), tags["anki"].ID, strings.NewReader(`{"name": "Morning"}`), controllers.APITagsUpdate)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = apiRequest(t, mockTagsJwtToken, echo.PUT, fmt.Sprintf("/api/tags/%d", tags["anki"].ID), tags["anki"].ID, strings.NewReader(`{"name": "Anki deck"}`), controllers.APITagsUpdate)
+	if assert.Equal(t, http.StatusOK, rec.Code) {
+		var body models.Tag
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "Anki deck", body.Name)
+		assert.Equal(t, uint64(1), body.Logs)
+	}
+
+	log, _ := logCollection.Get(logID)
+	assert.Equal(t, []string{"Anki deck", "morning"}, []string(log.Tags))
+	assert.Equal(t, uint64(2), log.Version)
+
+	// Deleting a tag removes it from its logs
+	rec = apiRequest(t, mockTagsJwtToken, echo.DELETE, fmt.Sprintf("/api/tags/%d", tags["morning"].ID), tags["morning"].ID, nil, controllers.APITagsDelete)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	log, _ = logCollection.Get(logID)
+	assert.Equal(t, []string{"Anki deck"}, []string(log.Tags))
+
+	rec = apiRequest(t, mockTagsJwtToken, echo.DELETE, fmt.Sprintf("/api/tags/%d", tags["morning"].ID), tags["morning"].ID, nil, controllers.APITagsDelete)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), controllers.ErrorCodeTagNotFound)
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// APITemplatesGetAll gets all log templates of the current user
+func APITemplatesGetAll(context echo.Context) error {
+	logTemplateCollection := models.LogTemplateCollection{Templates: make([]models.LogTemplate, 0)}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := logTemplateCollection.GetAllFromUser(user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, logTemplateCollection)
+}
+
+// APITemplatesPost adds a log template
+func APITemplatesPost(context echo.Context) error {
+	logTemplate := &models.LogTemplate{}
+
+	// Attempt to bind request to LogTemplate struct
+	err := context.Bind(logTemplate)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+	logTemplate.UserID = user.ID
+
+	// Validate request
+	err = logTemplate.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	// Save to database
+	logTemplateCollection := models.LogTemplateCollection{}
+	id, err := logTemplateCollection.Add(logTemplate)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	logTemplate, err = logTemplateCollection.Get(id)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	context.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/templates/%d", logTemplate.ID))
+
+	return context.JSON(http.StatusCreated, logTemplate)
+}
+
+// APITemplatesGetPlanned gets the sessions the current user planned on a day
+func APITemplatesGetPlanned(context echo.Context) error {
+	plannedSessionCollection := models.PlannedSessionCollection{Sessions: make([]models.PlannedSession, 0)}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := plannedSessionCollection.GetFromUser(user.ID, context.QueryParam("date"))
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, plannedSessionCollection)
+}
+
+// APITemplatesGetByID gets a single log template
+func APITemplatesGetByID(context echo.Context) error {
+	logTemplate, status, err := getOwnedLogTemplate(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	return context.JSON(http.StatusOK, logTemplate)
+}
+
+// APITemplatesUpdate updates a log template
+func APITemplatesUpdate(context echo.Context) error {
+	logTemplate := &models.LogTemplate{}
+
+	// Attempt to bind request to LogTemplate struct
+	err := context.Bind(logTemplate)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	currentLogTemplate, status, err := getOwnedLogTemplate(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	logTemplate.ID = currentLogTemplate.ID
+	logTemplate.UserID = currentLogTemplate.UserID
+
+	// Validate request
+	err = logTemplate.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	logTemplateCollection := models.LogTemplateCollection{}
+	err = logTemplateCollection.Update(logTemplate)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	logTemplate, err = logTemplateCollection.Get(logTemplate.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return context.JSON(http.StatusOK, logTemplate)
+}
+
+// APITemplatesDelete deletes a log template, logs made from it are kept
+func APITemplatesDelete(context echo.Context) error {
+	logTemplate, status, err := getOwnedLogTemplate(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	logTemplateCollection := models.LogTemplateCollection{}
+	err = logTemplateCollection.Delete(logTemplate, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	return Serve(context, 200)
+}
+
+// APITemplatesPostLog makes a log from a log template, the body with changes to the template is optional
+func APITemplatesPostLog(context echo.Context) error {
+	templateLog := &models.LogTemplateLog{}
+	if context.Request().ContentLength != 0 {
+		// Attempt to bind request to LogTemplateLog struct
+		err := context.Bind(templateLog)
+		if err != nil {
+			return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+		}
+	}
+
+	logTemplate, status, err := getOwnedLogTemplate(context)
+	if err != nil {
+		return ServeWithError(context, status, err)
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	logTemplateCollection := models.LogTemplateCollection{}
+	log, err := logTemplateCollection.AddLog(logTemplate, templateLog, user.ID)
+	if err != nil {
+		return ServeWithError(context, 500, err)
+	}
+
+	context.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/logs/%d", log.ID))
+	context.Response().Header().Set("ETag", formatETag(log.Version))
+
+	return context.JSON(http.StatusCreated, log)
+}
+
+// getOwnedLogTemplate gets the log template in the `id` route parameter when it belongs to the current user,
+// otherwise returns the error along with the status to serve it with
+func getOwnedLogTemplate(context echo.Context) (*models.LogTemplate, int, error) {
+	id, err := parseID(context)
+	if err != nil {
+		return nil, 400, err
+	}
+
+	logTemplateCollection := models.LogTemplateCollection{}
+	logTemplate, err := logTemplateCollection.Get(id)
+	if err != nil {
+		return nil, 500, err
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return nil, 500, fmt.Errorf("could not receive user")
+	}
+
+	if !logTemplate.IsOwner(user.ID) {
+		return nil, 403, fmt.Errorf("template doesn't belong to user")
+	}
+
+	return logTemplate, 0, nil
+}
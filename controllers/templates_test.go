@@ -0,0 +1,175 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/antonve/logger-api/utils"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockTemplatesJwtToken string
+var mockTemplatesUser *models.User
+
+func init() {
+	utils.SetupTesting()
+	mockTemplatesJwtToken, mockTemplatesUser = utils.SetupTestUser("templates_test")
+}
+
+func TestTemplatePost(t *testing.T) {
+	rec := apiRequest(t, mockTemplatesJwtToken, echo.POST, "/api/templates", 0, strings.NewReader(`{
+    "name": "Daily Anki",
+    "language": "ja",
+    "activity": "FLASHCARDS",
+    "duration": 20,
+    "tags": ["srs"],
+    "weekdays": [1, 3, 5]
+  }`), controllers.APITemplatesPost)
+	var body models.LogTemplate
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "Daily Anki", body.Name)
+	assert.Equal(t, uint64(20), body.Duration)
+	assert.Equal(t, []string{"srs"}, []string(body.Tags))
+	assert.Equal(t, []int64{1, 3, 5}, []int64(body.Weekdays))
+	assert.Equal(t, fmt.Sprintf("/api/templates/%d", body.ID), rec.Header().Get(echo.HeaderLocation))
+
+	// Names are unique regardless of case
+	rec = apiRequest(t, mockTemplatesJwtToken, echo.POST, "/api/templates", 0, strings.NewReader(`{"name": "daily anki", "language": "ja", "activity": "FLASHCARDS", "duration": 20}`), controllers.APITemplatesPost)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "another template already has this name")
+
+	// Weekdays go from monday to sunday and can only be used once
+	rec = apiRequest(t, mockTemplatesJwtToken, echo.POST, "/api/templates", 0, strings.NewReader(`{"name": "Weekly", "language": "ja", "activity": "FLASHCARDS", "duration": 20, "weekdays": [0, 8, 1, 1]}`), controllers.APITemplatesPost)
+	var errorBody controllers.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &errorBody))
+	assert.Len(t, errorBody.Details, 3)
+}
+
+func TestTemplatePostLog(t *testing.T) {
+	// Setup template
+	logTemplateCollection := models.LogTemplateCollection{}
+	templateID, err := logTemplateCollection.Add(&models.LogTemplate{UserID: mockTemplatesUser.ID, Name: "Evening podcast", Language: enums.LanguageJapanese, Activity: enums.ActivityListening, Duration: 30, Tags: []string{"podcast"}})
+	assert.Nil(t, err)
+
+	// Logs are made today without a body
+	rec := apiRequest(t, mockTemplatesJwtToken, echo.POST, fmt.Sprintf("/api/templates/%d/logs", templateID), templateID, nil, controllers.APITemplatesPostLog)
+	var log models.Log
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &log))
+	assert.Equal(t, time.Now().UTC().Format(models.DateFormat), log.Date)
+	assert.Equal(t, uint64(30), log.Duration)
+	assert.Equal(t, enums.ActivityListening, log.Activity)
+	assert.Equal(t, []string{"podcast"}, []string(log.Tags))
+	if assert.NotNil(t, log.TemplateID) {
+		assert.Equal(t, templateID, *log.TemplateID)
+	}
+	assert.Equal(t, fmt.Sprintf("/api/logs/%d", log.ID), rec.Header().Get(echo.HeaderLocation))
+
+	// Fields of the template can be changed for a single log
+	rec = apiRequest(t, mockTemplatesJwtToken, echo.POST, fmt.Sprintf("/api/templates/%d/logs", templateID), templateID, strings.NewReader(`{"date": "2017-10-01", "duration": 15}`), controllers.APITemplatesPostLog)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &log))
+	assert.Equal(t, "2017-10-01", log.Date)
+	assert.Equal(t, uint64(15), log.Duration)
+
+	// Templates of other users can't be used
+	otherTemplateID, err := logTemplateCollection.Add(&models.LogTemplate{UserID: mockLogsUser.ID, Name: "Evening podcast", Language: enums.LanguageJapanese, Activity: enums.ActivityListening, Duration: 30})
+	assert.Nil(t, err)
+	rec = apiRequest(t, mockTemplatesJwtToken, echo.POST, fmt.Sprintf("/api/templates/%d/logs", otherTemplateID), otherTemplateID, nil, controllers.APITemplatesPostLog)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = apiRequest(t, mockTemplatesJwtToken, echo.POST, "/api/templates/9000000/logs", 9000000, nil, controllers.APITemplatesPostLog)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), controllers.ErrorCodeTemplateNotFound)
+}
+
+func TestTemplateTagChanges(t *testing.T) {
+	// Setup a template with tags that exist because a log has them
+	token, user := utils.SetupTestUser("template_tags_test")
+	logCollection := models.LogCollection{}
+	_, err := logCollection.Add(&models.Log{UserID: user.ID, Language: enums.LanguageJapanese, Date: "2017-10-01", Duration: 20, Activity: enums.ActivityFlashcards, Tags: []string{"srs", "morning"}}, user.ID)
+	assert.Nil(t, err)
+
+	logTemplateCollection := models.LogTemplateCollection{}
+	templateID, err := logTemplateCollection.Add(&models.LogTemplate{UserID: user.ID, Name: "Morning Anki", Language: enums.LanguageJapanese, Activity: enums.ActivityFlashcards, Duration: 20, Tags: []string{"SRS", "morning"}})
+	assert.Nil(t, err)
+
+	tagCollection := models.TagCollection{}
+	assert.Nil(t, tagCollection.GetAllFromUser(user.ID))
+	tags := make(map[string]models.Tag)
+	for _, tag := range tagCollection.Tags {
+		tags[tag.Name] = tag
+	}
+
+	// Renamed tags are renamed in templates as well
+	rec := apiRequest(t, token, echo.PUT, fmt.Sprintf("/api/tags/%d", tags["srs"].ID), tags["srs"].ID, strings.NewReader(`{"name": "Anki"}`), controllers.APITagsUpdate)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	logTemplate, err := logTemplateCollection.Get(templateID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Anki", "morning"}, []string(logTemplate.Tags))
+
+	// Deleted tags are removed from templates so they aren't created again by the next log
+	rec = apiRequest(t, token, echo.DELETE, fmt.Sprintf("/api/tags/%d", tags["morning"].ID), tags["morning"].ID, nil, controllers.APITagsDelete)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	logTemplate, err = logTemplateCollection.Get(templateID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Anki"}, []string(logTemplate.Tags))
+}
+
+func TestTemplatesPlanned(t *testing.T) {
+	// Setup templates planned on mondays, and one that isn't recurring
+	logTemplateCollection := models.LogTemplateCollection{}
+	mondayID, err := logTemplateCollection.Add(&models.LogTemplate{UserID: mockTemplatesUser.ID, Name: "Monday grammar", Language: enums.LanguageJapanese, Activity: enums.ActivityGrammar, Duration: 45, Weekdays: []int64{1}})
+	assert.Nil(t, err)
+	_, err = logTemplateCollection.Add(&models.LogTemplate{UserID: mockTemplatesUser.ID, Name: "Any day", Language: enums.LanguageJapanese, Activity: enums.ActivityReading, Duration: 45})
+	assert.Nil(t, err)
+
+	mondayTemplate, _ := logTemplateCollection.Get(mondayID)
+	log, err := logTemplateCollection.AddLog(mondayTemplate, &models.LogTemplateLog{Date: "2017-10-09"}, mockTemplatesUser.ID)
+	assert.Nil(t, err)
+
+	getPlanned := func(date string) (*httptest.ResponseRecorder, models.PlannedSessionCollection) {
+		rec := apiRequest(t, mockTemplatesJwtToken, echo.GET, "/api/templates/planned?date="+date, 0, nil, controllers.APITemplatesGetPlanned)
+		var body models.PlannedSessionCollection
+		if rec.Code == http.StatusOK {
+			assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		}
+
+		return rec, body
+	}
+
+	// 2017-10-02 and 2017-10-09 are mondays, the session on the 9th was logged
+	rec, body := getPlanned("2017-10-02")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2017-10-02", body.Date)
+	if assert.Len(t, body.Sessions, 1) {
+		assert.Equal(t, mondayID, body.Sessions[0].ID)
+		assert.Nil(t, body.Sessions[0].LogID)
+	}
+
+	rec, body = getPlanned("2017-10-09")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	if assert.Len(t, body.Sessions, 1) && assert.NotNil(t, body.Sessions[0].LogID) {
+		assert.Equal(t, log.ID, *body.Sessions[0].LogID)
+	}
+
+	rec, body = getPlanned("2017-10-03")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, body.Sessions, 0)
+
+	rec, _ = getPlanned("03-10-2017")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
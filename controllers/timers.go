@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+)
+
+// timerErrors maps the errors of timers that can't be changed onto their status and error code
+var timerErrors = map[error]struct {
+	status int
+	code   string
+}{
+	models.ErrTimerNotFound:       {http.StatusNotFound, ErrorCodeTimerNotFound},
+	models.ErrTimerAlreadyStarted: {http.StatusConflict, ErrorCodeTimerAlreadyStarted},
+	models.ErrTimerNotRunning:     {http.StatusConflict, ErrorCodeTimerNotRunning},
+	models.ErrTimerNotPaused:      {http.StatusConflict, ErrorCodeTimerNotPaused},
+	models.ErrTimerNotPomodoro:    {http.StatusConflict, ErrorCodeTimerNotPomodoro},
+	models.ErrTimerOnBreak:        {http.StatusConflict, ErrorCodeTimerOnBreak},
+	models.ErrTimerNotOnBreak:     {http.StatusConflict, ErrorCodeTimerNotOnBreak},
+}
+
+// TimerBreakResponse is sent when a pomodoro timer goes on a break
+type TimerBreakResponse struct {
+	Timer *models.Timer `json:"timer"`
+
+	// Log of the focus interval that ended, not set when it lasted less than a minute
+	Log *models.Log `json:"log"`
+}
+
+// APITimersStart starts a timer for the current user
+func APITimersStart(context echo.Context) error {
+	timer := &models.Timer{}
+
+	// Attempt to bind request to Timer struct
+	err := context.Bind(timer)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+	timer.UserID = user.ID
+
+	// Validate request
+	err = timer.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
+	err = timer.Start()
+	if err != nil {
+		return serveTimerError(context, err)
+	}
+
+	context.Response().Header().Set(echo.HeaderLocation, "/api/timers/current")
+
+	return context.JSON(http.StatusCreated, timer)
+}
+
+// APITimersGetCurrent gets the timer of the current user
+func APITimersGetCurrent(context echo.Context) error {
+	timer := &models.Timer{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := timer.GetFromUser(user.ID)
+	if err != nil {
+		return serveTimerError(context, err)
+	}
+
+	return context.JSON(http.StatusOK, timer)
+}
+
+// APITimersPause pauses the timer of the current user
+func APITimersPause(context echo.Context) error {
+	timer := &models.Timer{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := timer.Pause(user.ID)
+	if err != nil {
+		return serveTimerError(context, err)
+	}
+
+	return context.JSON(http.StatusOK, timer)
+}
+
+// APITimersResume resumes the paused timer of the current user
+func APITimersResume(context echo.Context) error {
+	timer := &models.Timer{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := timer.Resume(user.ID)
+	if err != nil {
+		return serveTimerError(context, err)
+	}
+
+	return context.JSON(http.StatusOK, timer)
+}
+
+// APITimersBreak ends the focus interval of the pomodoro timer of the current user and starts a break
+func APITimersBreak(context echo.Context) error {
+	stop, err := bindTimerStop(context)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	timer := &models.Timer{}
+	log, err := timer.Break(user.ID, stop)
+	if err != nil {
+		return serveTimerError(context, err)
+	}
+
+	return context.JSON(http.StatusOK, TimerBreakResponse{Timer: timer, Log: log})
+}
+
+// APITimersFocus ends the break of the pomodoro timer of the current user and starts the next focus interval
+func APITimersFocus(context echo.Context) error {
+	timer := &models.Timer{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := timer.Focus(user.ID)
+	if err != nil {
+		return serveTimerError(context, err)
+	}
+
+	return context.JSON(http.StatusOK, timer)
+}
+
+// APITimersStop stops the timer of the current user and logs the time it ran
+func APITimersStop(context echo.Context) error {
+	stop, err := bindTimerStop(context)
+	if err != nil {
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
+	}
+
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	timer := &models.Timer{}
+	log, err := timer.Stop(user.ID, stop)
+	if err != nil {
+		return serveTimerError(context, err)
+	}
+
+	// Timers stopped within a minute and pomodoro timers stopped on a break have nothing left to log
+	if log == nil {
+		return Serve(context, 200)
+	}
+
+	context.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/logs/%d", log.ID))
+	context.Response().Header().Set("ETag", formatETag(log.Version))
+
+	return context.JSON(http.StatusCreated, log)
+}
+
+// APITimersDiscard throws away the timer of the current user without logging it
+func APITimersDiscard(context echo.Context) error {
+	timer := &models.Timer{}
+	user := getUser(context)
+	if user == nil {
+		return ServeWithError(context, 500, fmt.Errorf("could not receive user"))
+	}
+
+	err := timer.Discard(user.ID)
+	if err != nil {
+		return serveTimerError(context, err)
+	}
+
+	return Serve(context, 200)
+}
+
+// bindTimerStop binds the optional body sent along when a timer is stopped or goes on a break
+func bindTimerStop(context echo.Context) (*models.TimerStop, error) {
+	stop := &models.TimerStop{}
+	if context.Request().ContentLength == 0 {
+		return stop, nil
+	}
+
+	return stop, context.Bind(stop)
+}
+
+// serveTimerError serves errors of timers that can't be changed in their current state with their own code
+func serveTimerError(context echo.Context, err error) error {
+	if timerError, ok := timerErrors[err]; ok {
+		return ServeWithError(context, timerError.status, withCode(timerError.code, err))
+	}
+
+	return ServeWithError(context, 500, err)
+}
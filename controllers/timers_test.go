@@ -0,0 +1,251 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonve/logger-api/controllers"
+	"github.com/antonve/logger-api/models"
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/antonve/logger-api/utils"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockTimersJwtToken string
+var mockTimersUser *models.User
+var mockPomodoroJwtToken string
+var mockPomodoroUser *models.User
+
+func init() {
+	utils.SetupTesting()
+	mockTimersJwtToken, mockTimersUser = utils.SetupTestUser("timers_test")
+	mockPomodoroJwtToken, mockPomodoroUser = utils.SetupTestUser("pomodoro_test")
+}
+
+func TestTimerLifecycle(t *testing.T) {
+	// Start a timer
+	rec := apiRequest(t, mockTimersJwtToken, echo.POST, "/api/timers", 0, strings.NewReader(`{
+    "language": "JA",
+    "activity": "READING",
+    "notes": {"type": "BOOK", "series": "キングダム"}
+  }`), controllers.APITimersStart)
+	var timer models.Timer
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &timer))
+	assert.Equal(t, mockTimersUser.ID, timer.UserID)
+	assert.Equal(t, enums.ActivityReading, timer.Activity)
+	assert.True(t, timer.Running)
+	assert.Equal(t, "/api/timers/current", rec.Header().Get(echo.HeaderLocation))
+
+	// Only one timer can be started at a time
+	rec = apiRequest(t, mockTimersJwtToken, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "LISTENING"}`), controllers.APITimersStart)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), controllers.ErrorCodeTimerAlreadyStarted)
+
+	// Pause and resume it
+	rec = apiRequest(t, mockTimersJwtToken, echo.POST, "/api/timers/current/pause", 0, nil, controllers.APITimersPause)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &timer))
+	assert.False(t, timer.Running)
+	assert.Nil(t, timer.ResumedAt)
+
+	rec = apiRequest(t, mockTimersJwtToken, echo.POST, "/api/timers/current/pause", 0, nil, controllers.APITimersPause)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), controllers.ErrorCodeTimerNotRunning)
+
+	rec = apiRequest(t, mockTimersJwtToken, echo.POST, "/api/timers/current/resume", 0, nil, controllers.APITimersResume)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = apiRequest(t, mockTimersJwtToken, echo.GET, "/api/timers/current", 0, nil, controllers.APITimersGetCurrent)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &timer))
+	assert.True(t, timer.Running)
+
+	// Pretend the timer ran for 25 minutes
+	_, err := models.GetDatabase().Exec(`UPDATE timers SET elapsed_seconds = 1500, resumed_at = NULL WHERE user_id = $1`, mockTimersUser.ID)
+	assert.Nil(t, err)
+
+	rec = apiRequest(t, mockTimersJwtToken, echo.POST, "/api/timers/current/stop", 0, strings.NewReader(`{
+    "date": "2017-02-01",
+    "notes": {"type": "BOOK", "series": "キングダム", "pages": 40}
+  }`), controllers.APITimersStop)
+	var log models.Log
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &log))
+	assert.Equal(t, uint64(25), log.Duration)
+	assert.Equal(t, "2017-02-01", log.Date)
+	assert.Equal(t, enums.ActivityReading, log.Activity)
+	assert.Contains(t, string(log.Notes), `"pages": 40`)
+	// The log was moved to another day so it doesn't keep when the timer ran
+	assert.Nil(t, log.StartedAt)
+	assert.Nil(t, log.EndedAt)
+	assert.Equal(t, fmt.Sprintf("/api/logs/%d", log.ID), rec.Header().Get(echo.HeaderLocation))
+
+	// The timer is gone once it's logged
+	rec = apiRequest(t, mockTimersJwtToken, echo.GET, "/api/timers/current", 0, nil, controllers.APITimersGetCurrent)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), controllers.ErrorCodeTimerNotFound)
+}
+
+func TestTimerStopPaused(t *testing.T) {
+	token, user := utils.SetupTestUser("timer_stop_paused_test")
+
+	// A timer that barely ran has nothing to log
+	rec := apiRequest(t, token, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "LISTENING"}`), controllers.APITimersStart)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = apiRequest(t, token, echo.POST, "/api/timers/current/stop", 0, nil, controllers.APITimersStop)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = apiRequest(t, token, echo.GET, "/api/timers/current", 0, nil, controllers.APITimersGetCurrent)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Pretend the timer ran for 10 minutes and was paused for an hour after that
+	rec = apiRequest(t, token, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "LISTENING"}`), controllers.APITimersStart)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = apiRequest(t, token, echo.POST, "/api/timers/current/pause", 0, nil, controllers.APITimersPause)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	_, err := models.GetDatabase().Exec(`
+		UPDATE timers
+		SET
+			elapsed_seconds = 600,
+			started_at = started_at - interval '70 minutes',
+			phase_started_at = phase_started_at - interval '70 minutes'
+		WHERE user_id = $1
+	`, user.ID)
+	assert.Nil(t, err)
+
+	// Only the time the timer ran is logged, when the session ended isn't known
+	rec = apiRequest(t, token, echo.POST, "/api/timers/current/stop", 0, nil, controllers.APITimersStop)
+	var log models.Log
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &log))
+	assert.Equal(t, uint64(10), log.Duration)
+	assert.NotNil(t, log.StartedAt)
+	assert.Nil(t, log.EndedAt)
+}
+
+func TestTimerStopAfterMaxDuration(t *testing.T) {
+	token, user := utils.SetupTestUser("timer_stop_max_test")
+
+	// Pretend the timer was left running for 30 hours
+	rec := apiRequest(t, token, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "LISTENING"}`), controllers.APITimersStart)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	_, err := models.GetDatabase().Exec(`
+		UPDATE timers
+		SET
+			started_at = started_at - interval '30 hours',
+			phase_started_at = phase_started_at - interval '30 hours',
+			resumed_at = resumed_at - interval '30 hours'
+		WHERE user_id = $1
+	`, user.ID)
+	assert.Nil(t, err)
+
+	// It's logged as if it stopped after the longest duration a log can have
+	rec = apiRequest(t, token, echo.POST, "/api/timers/current/stop", 0, nil, controllers.APITimersStop)
+	var log models.Log
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &log))
+	assert.Equal(t, uint64(models.MaxLogDuration), log.Duration)
+	if assert.NotNil(t, log.StartedAt) && assert.NotNil(t, log.EndedAt) {
+		assert.Equal(t, models.MaxLogDuration*time.Minute, log.EndedAt.Sub(*log.StartedAt))
+	}
+
+	rec = apiRequest(t, token, echo.GET, "/api/timers/current", 0, nil, controllers.APITimersGetCurrent)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTimerDiscard(t *testing.T) {
+	rec := apiRequest(t, mockTimersJwtToken, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "XX"}`), controllers.APITimersStart)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = apiRequest(t, mockTimersJwtToken, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "LISTENING"}`), controllers.APITimersStart)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = apiRequest(t, mockTimersJwtToken, echo.DELETE, "/api/timers/current", 0, nil, controllers.APITimersDiscard)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = apiRequest(t, mockTimersJwtToken, echo.DELETE, "/api/timers/current", 0, nil, controllers.APITimersDiscard)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTimerPomodoro(t *testing.T) {
+	// Interval lengths can only be set for pomodoro timers
+	rec := apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "GRAMMAR", "focus_minutes": 25}`), controllers.APITimersStart)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "focus_minutes")
+
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "GRAMMAR", "mode": "POMODORO", "focus_minutes": 20}`), controllers.APITimersStart)
+	var timer models.Timer
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &timer))
+	assert.Equal(t, enums.TimerModePomodoro, timer.Mode)
+	assert.Equal(t, enums.TimerPhaseFocus, timer.Phase)
+	if assert.NotNil(t, timer.FocusMinutes) && assert.NotNil(t, timer.BreakMinutes) {
+		assert.Equal(t, uint64(20), *timer.FocusMinutes)
+		assert.Equal(t, uint64(models.DefaultBreakMinutes), *timer.BreakMinutes)
+	}
+
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers/current/focus", 0, nil, controllers.APITimersFocus)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), controllers.ErrorCodeTimerNotOnBreak)
+
+	// Pretend the focus interval ran for 20 minutes, it's logged when the break starts
+	_, err := models.GetDatabase().Exec(`UPDATE timers SET elapsed_seconds = 1200, resumed_at = NULL WHERE user_id = $1`, mockPomodoroUser.ID)
+	assert.Nil(t, err)
+
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers/current/break", 0, nil, controllers.APITimersBreak)
+	var breakBody controllers.TimerBreakResponse
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &breakBody))
+	if assert.NotNil(t, breakBody.Timer) {
+		assert.Equal(t, enums.TimerPhaseBreak, breakBody.Timer.Phase)
+		assert.Equal(t, uint64(1), breakBody.Timer.Pomodoros)
+		assert.True(t, breakBody.Timer.Running)
+	}
+	if assert.NotNil(t, breakBody.Log) {
+		assert.Equal(t, uint64(20), breakBody.Log.Duration)
+		assert.Equal(t, enums.ActivityGrammar, breakBody.Log.Activity)
+	}
+
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers/current/break", 0, nil, controllers.APITimersBreak)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), controllers.ErrorCodeTimerOnBreak)
+
+	// Breaks aren't logged
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers/current/focus", 0, nil, controllers.APITimersFocus)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &timer))
+	assert.Equal(t, enums.TimerPhaseFocus, timer.Phase)
+
+	// Stopping right after a break has nothing to log
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers/current/stop", 0, nil, controllers.APITimersStop)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The completed focus interval is tallied
+	today := time.Now().UTC().Format(models.DateFormat)
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.GET, "/api/stats/pomodoros?from="+today, 0, nil, controllers.APIStatsPomodoros)
+	var tally models.PomodoroTally
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &tally))
+	if assert.Len(t, tally.Days, 1) {
+		assert.Equal(t, today, tally.Days[0].Date)
+		assert.Equal(t, uint64(1), tally.Days[0].Completed)
+		assert.Equal(t, uint64(20), tally.Days[0].FocusDuration)
+	}
+
+	// Standard timers don't take breaks
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers", 0, strings.NewReader(`{"language": "JA", "activity": "GRAMMAR"}`), controllers.APITimersStart)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.POST, "/api/timers/current/break", 0, nil, controllers.APITimersBreak)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), controllers.ErrorCodeTimerNotPomodoro)
+	rec = apiRequest(t, mockPomodoroJwtToken, echo.DELETE, "/api/timers/current", 0, nil, controllers.APITimersDiscard)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
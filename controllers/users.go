@@ -3,12 +3,10 @@ package controllers
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/antonve/logger-api/models"
 	"github.com/antonve/logger-api/models/enums"
 
-	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 )
 
@@ -28,12 +26,12 @@ func APIUserGetAll(context echo.Context) error {
 func APIUserGetByID(context echo.Context) error {
 	userCollection := models.UserCollection{}
 
-	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	id, err := parseID(context)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, err)
 	}
 
-	currentUser := context.Get("currentUser").(*jwt.Token).Claims.(*models.JwtClaims).User
+	currentUser := getUser(context)
 	if !(currentUser != nil && (currentUser.ID == id || currentUser.Role == enums.RoleAdmin)) {
 		return ServeWithError(context, 403, fmt.Errorf("not allowed to access this user"))
 	}
@@ -44,7 +42,7 @@ func APIUserGetByID(context echo.Context) error {
 	}
 
 	if user == nil {
-		return ServeWithError(context, 404, fmt.Errorf("no User found with id %v", id))
+		return ServeWithError(context, 404, &models.NotFoundError{Resource: "user", ID: id})
 	}
 
 	return context.JSON(http.StatusOK, user)
@@ -57,13 +55,13 @@ func APIUserUpdate(context echo.Context) error {
 	// Attempt to bind request to User struct
 	err := context.Bind(user)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, withCode(ErrorCodeInvalidBody, err))
 	}
 
 	// Parse out id
-	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	id, err := parseID(context)
 	if err != nil {
-		return ServeWithError(context, 500, err)
+		return ServeWithError(context, 400, err)
 	}
 	user.ID = id
 
@@ -81,6 +79,12 @@ func APIUserUpdate(context echo.Context) error {
 		user.Role = currentUser.Role
 	}
 
+	// Validate request
+	err = user.Validate()
+	if err != nil {
+		return ServeWithError(context, 400, err)
+	}
+
 	// Update
 	userCollection := models.UserCollection{}
 	err = userCollection.Update(user)
@@ -86,3 +86,42 @@ func TestUserUpdate(t *testing.T) {
 		assert.Equal(t, []enums.Language{enums.LanguageJapanese, enums.LanguageKorean, enums.LanguageMandarin}, updatedUser.Preferences.Languages)
 	}
 }
+
+func TestUserUpdateTimezone(t *testing.T) {
+	token, user := utils.SetupTestUser("timezone_test")
+	timezones := []struct {
+		timezone string
+		status   int
+	}{
+		{"Europe/Brussels", http.StatusOK},
+		{"Mars/Olympus_Mons", http.StatusBadRequest},
+	}
+
+	for _, timezone := range timezones {
+		// Setup update request
+		e := echo.New()
+		updatedUser := strings.NewReader(fmt.Sprintf(`{
+		"email": "%s",
+		"display_name": "%s",
+		"preferences": {
+			"timezone": "%s"
+		}
+  }`, user.Email, user.DisplayName, timezone.timezone))
+		req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/api/user/%d", user.ID), updatedUser)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/user/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(fmt.Sprintf("%d", user.ID))
+
+		if assert.NoError(t, middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))(controllers.APIUserUpdate)(c)) {
+			assert.Equal(t, timezone.status, rec.Code, timezone.timezone)
+		}
+	}
+
+	userCollection := models.UserCollection{}
+	updatedUser, _ := userCollection.Get(user.ID)
+	assert.Equal(t, "Europe/Brussels", updatedUser.Preferences.Timezone)
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"github.com/antonve/logger-api/config"
 	"github.com/antonve/logger-api/migrations"
+	"github.com/antonve/logger-api/models"
 	"github.com/antonve/logger-api/utils"
 
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
+	"github.com/tylerb/graceful"
 
 	"log"
 )
@@ -19,7 +22,7 @@ func main() {
 
 	// Middleware
 	e.Use(middleware.Recover())
-	defer utils.SetupErrorLogging(e)()
+	closeErrorLog := utils.SetupErrorLogging(e)
 
 	// Serve static assets
 	// utils.SetupStaticAssets(e)
@@ -27,6 +30,23 @@ func main() {
 	// Routes
 	utils.SetupRouting(e)
 
-	// Start server
-	e.Start(":7000")
+	// Background tasks
+	stopTrashPurger := utils.StartTrashPurger(e)
+	stopLogSearchBackfill := utils.StartLogSearchBackfill(e)
+
+	// Start server, on SIGINT or SIGTERM we stop accepting new connections
+	// and give in-flight requests some time to finish before returning
+	e.Server.Addr = ":7000"
+	err := graceful.ListenAndServe(e.Server, config.GetConfig().GetShutdownTimeout())
+	if err != nil {
+		log.Println(err)
+	}
+
+	log.Println("Shutting down Logger API")
+
+	// Release resources once no requests are being handled anymore
+	stopTrashPurger()
+	stopLogSearchBackfill()
+	models.CloseDatabase()
+	closeErrorLog()
 }
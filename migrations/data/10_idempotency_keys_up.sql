@@ -0,0 +1,15 @@
+CREATE TABLE idempotency_keys (
+  user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  key varchar(255) NOT NULL,
+  method varchar(10) NOT NULL,
+  path text NOT NULL,
+  request_hash char(64) NOT NULL,
+  status_code integer,
+  content_type varchar(255),
+  response_body bytea,
+  response_headers jsonb,
+  created_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
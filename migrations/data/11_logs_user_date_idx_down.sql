@@ -0,0 +1 @@
+DROP INDEX logs_user_id_date_idx;
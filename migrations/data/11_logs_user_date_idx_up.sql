@@ -0,0 +1 @@
+CREATE INDEX logs_user_id_date_idx ON logs (user_id, date DESC) WHERE deleted = FALSE;
@@ -0,0 +1,3 @@
+DROP INDEX logs_search_vector_idx;
+
+ALTER TABLE logs DROP COLUMN search_vector;
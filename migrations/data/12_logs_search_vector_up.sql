@@ -0,0 +1,3 @@
+ALTER TABLE logs ADD COLUMN search_vector tsvector;
+
+CREATE INDEX logs_search_vector_idx ON logs USING GIN (search_vector);
@@ -0,0 +1,11 @@
+UPDATE logs
+SET
+  notes = legacy_log_notes.notes,
+  search_vector = NULL,
+  version = version + 1,
+  updated_at = (current_timestamp AT TIME ZONE 'UTC'),
+  change_seq = NEXTVAL('logs_change_seq')
+FROM legacy_log_notes
+WHERE logs.id = legacy_log_notes.log_id;
+
+DROP TABLE legacy_log_notes;
@@ -0,0 +1,61 @@
+CREATE TABLE legacy_log_notes (
+  log_id bigint NOT NULL REFERENCES logs (id) ON DELETE CASCADE,
+  notes jsonb NOT NULL,
+  created_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  PRIMARY KEY (log_id)
+);
+
+INSERT INTO legacy_log_notes (log_id, notes)
+SELECT id, notes
+FROM logs
+WHERE
+  notes IS NOT NULL AND
+  jsonb_typeof(notes) <> 'null' AND (
+    jsonb_typeof(notes) <> 'object' OR
+    EXISTS (
+      SELECT 1
+      FROM jsonb_each(notes) AS field
+      WHERE NOT (
+        (
+          field.key = 'comment' AND
+          (jsonb_typeof(field.value) = 'null' OR (jsonb_typeof(field.value) = 'string' AND length(field.value #>> '{}') <= 2000))
+        ) OR (
+          field.key = ANY(CASE CAST(activity AS text)
+            WHEN 'READING' THEN ARRAY['series']
+            WHEN 'LISTENING' THEN ARRAY['source']
+            WHEN 'FLASHCARDS' THEN ARRAY['deck']
+            WHEN 'TEXTBOOK' THEN ARRAY['book', 'chapter']
+            WHEN 'TRANSLATION' THEN ARRAY['source']
+            WHEN 'GRAMMAR' THEN ARRAY['source']
+            ELSE CAST(ARRAY[] AS text[])
+          END) AND
+          (jsonb_typeof(field.value) = 'null' OR (jsonb_typeof(field.value) = 'string' AND length(field.value #>> '{}') <= 255))
+        ) OR (
+          field.key = ANY(CASE CAST(activity AS text)
+            WHEN 'READING' THEN ARRAY['volume', 'pages', 'characters']
+            WHEN 'LISTENING' THEN ARRAY['episode']
+            WHEN 'FLASHCARDS' THEN ARRAY['reviews', 'new_cards']
+            WHEN 'TEXTBOOK' THEN ARRAY['pages']
+            WHEN 'TRANSLATION' THEN ARRAY['characters']
+            WHEN 'GRAMMAR' THEN ARRAY['points']
+            ELSE CAST(ARRAY[] AS text[])
+          END) AND
+          (jsonb_typeof(field.value) = 'null' OR (jsonb_typeof(field.value) = 'number' AND (field.value #>> '{}') ~ '^[0-9]+$'))
+        ) OR (
+          CAST(activity AS text) = 'READING' AND
+          field.key = 'type' AND
+          (jsonb_typeof(field.value) = 'null' OR (field.value #>> '{}') IN ('', 'BOOK', 'MANGA', 'ARTICLE', 'NEWS', 'WEB', 'OTHER'))
+        )
+      )
+    )
+  );
+
+UPDATE logs
+SET
+  notes = jsonb_build_object('comment', left(CAST(legacy_log_notes.notes AS text), 2000)),
+  search_vector = NULL,
+  version = version + 1,
+  updated_at = (current_timestamp AT TIME ZONE 'UTC'),
+  change_seq = NEXTVAL('logs_change_seq')
+FROM legacy_log_notes
+WHERE logs.id = legacy_log_notes.log_id;
@@ -0,0 +1,9 @@
+DROP INDEX logs_resource_id_idx;
+
+ALTER TABLE logs DROP COLUMN resource_id;
+
+DROP TABLE resources;
+
+DROP TYPE resource_type;
+
+DROP SEQUENCE resources_seq;
@@ -0,0 +1,27 @@
+CREATE SEQUENCE resources_seq;
+
+CREATE TYPE resource_type AS ENUM ('BOOK','SHOW','PODCAST','DECK','TEXTBOOK','OTHER');
+
+CREATE TABLE resources (
+  id bigint check (id > 0) NOT NULL DEFAULT NEXTVAL ('resources_seq'),
+  user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  title varchar(255) NOT NULL,
+  type resource_type NOT NULL,
+  language language NOT NULL,
+  total_pages bigint check (total_pages > 0),
+  total_episodes bigint check (total_episodes > 0),
+  total_cards bigint check (total_cards > 0),
+  cover_url text,
+  external_url text,
+  created_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  updated_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX resources_user_id_idx ON resources (user_id);
+
+ALTER TABLE logs ADD COLUMN resource_id bigint REFERENCES resources (id) ON DELETE SET NULL;
+
+CREATE INDEX logs_resource_id_idx ON logs (resource_id);
+
+ALTER SEQUENCE resources_seq RESTART WITH 1;
@@ -0,0 +1,2 @@
+ALTER TABLE logs DROP COLUMN characters_read;
+ALTER TABLE logs DROP COLUMN pages_read;
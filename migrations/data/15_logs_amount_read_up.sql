@@ -0,0 +1,15 @@
+ALTER TABLE logs ADD COLUMN pages_read bigint;
+ALTER TABLE logs ADD COLUMN characters_read bigint;
+
+UPDATE logs
+SET
+  pages_read = CASE WHEN jsonb_typeof(notes -> 'pages') = 'number' AND CAST(notes ->> 'pages' AS numeric) > 0 THEN CAST(notes ->> 'pages' AS bigint) END,
+  characters_read = CASE WHEN jsonb_typeof(notes -> 'characters') = 'number' AND CAST(notes ->> 'characters' AS numeric) > 0 THEN CAST(notes ->> 'characters' AS bigint) END,
+  version = version + 1,
+  updated_at = (current_timestamp AT TIME ZONE 'UTC'),
+  change_seq = NEXTVAL('logs_change_seq')
+WHERE
+  CAST(activity AS text) IN ('READING', 'TEXTBOOK', 'TRANSLATION') AND (
+    (jsonb_typeof(notes -> 'pages') = 'number' AND CAST(notes ->> 'pages' AS numeric) > 0) OR
+    (jsonb_typeof(notes -> 'characters') = 'number' AND CAST(notes ->> 'characters' AS numeric) > 0)
+  );
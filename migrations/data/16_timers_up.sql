@@ -0,0 +1,11 @@
+CREATE TABLE timers (
+  user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  language language NOT NULL,
+  activity activity NOT NULL,
+  notes jsonb,
+  resource_id bigint REFERENCES resources (id) ON DELETE SET NULL,
+  started_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  resumed_at timestamp DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  elapsed_seconds bigint NOT NULL DEFAULT 0 check (elapsed_seconds >= 0),
+  PRIMARY KEY (user_id)
+);
@@ -0,0 +1,15 @@
+DROP TABLE pomodoro_intervals;
+
+DROP SEQUENCE pomodoro_intervals_seq;
+
+ALTER TABLE timers
+  DROP COLUMN mode,
+  DROP COLUMN phase,
+  DROP COLUMN phase_started_at,
+  DROP COLUMN focus_minutes,
+  DROP COLUMN break_minutes,
+  DROP COLUMN pomodoros;
+
+DROP TYPE timer_phase;
+
+DROP TYPE timer_mode;
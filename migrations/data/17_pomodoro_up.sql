@@ -0,0 +1,27 @@
+CREATE TYPE timer_mode AS ENUM ('STANDARD','POMODORO');
+
+CREATE TYPE timer_phase AS ENUM ('FOCUS','BREAK');
+
+ALTER TABLE timers
+  ADD COLUMN mode timer_mode NOT NULL DEFAULT 'STANDARD',
+  ADD COLUMN phase timer_phase NOT NULL DEFAULT 'FOCUS',
+  ADD COLUMN phase_started_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  ADD COLUMN focus_minutes integer check (focus_minutes > 0),
+  ADD COLUMN break_minutes integer check (break_minutes > 0),
+  ADD COLUMN pomodoros integer NOT NULL DEFAULT 0;
+
+CREATE SEQUENCE pomodoro_intervals_seq;
+
+CREATE TABLE pomodoro_intervals (
+  id bigint check (id > 0) NOT NULL DEFAULT NEXTVAL ('pomodoro_intervals_seq'),
+  user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  phase timer_phase NOT NULL,
+  started_at timestamp NOT NULL,
+  ended_at timestamp NOT NULL,
+  duration bigint NOT NULL check (duration >= 0),
+  completed boolean NOT NULL DEFAULT FALSE,
+  log_id bigint REFERENCES logs (id) ON DELETE SET NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX pomodoro_intervals_user_id_started_at_idx ON pomodoro_intervals (user_id, started_at);
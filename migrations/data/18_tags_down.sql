@@ -0,0 +1,5 @@
+DROP TABLE log_tags;
+
+DROP TABLE tags;
+
+DROP SEQUENCE tags_seq;
@@ -0,0 +1,21 @@
+CREATE SEQUENCE tags_seq;
+
+CREATE TABLE tags (
+  id bigint check (id > 0) NOT NULL DEFAULT NEXTVAL ('tags_seq'),
+  user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name varchar(50) NOT NULL,
+  created_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX tags_user_id_name_idx ON tags (user_id, lower(name));
+
+CREATE TABLE log_tags (
+  log_id bigint NOT NULL REFERENCES logs (id) ON DELETE CASCADE,
+  tag_id bigint NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+  PRIMARY KEY (log_id, tag_id)
+);
+
+CREATE INDEX log_tags_tag_id_idx ON log_tags (tag_id);
+
+ALTER SEQUENCE tags_seq RESTART WITH 1;
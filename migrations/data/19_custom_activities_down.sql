@@ -0,0 +1,7 @@
+DROP INDEX logs_custom_activity_id_idx;
+
+ALTER TABLE logs DROP COLUMN custom_activity_id;
+
+DROP TABLE custom_activities;
+
+DROP SEQUENCE custom_activities_seq;
@@ -0,0 +1,19 @@
+CREATE SEQUENCE custom_activities_seq;
+
+CREATE TABLE custom_activities (
+  id bigint check (id > 0) NOT NULL DEFAULT NEXTVAL ('custom_activities_seq'),
+  user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name varchar(50) NOT NULL,
+  category activity NOT NULL,
+  created_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  updated_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX custom_activities_user_id_name_idx ON custom_activities (user_id, lower(name));
+
+ALTER TABLE logs ADD COLUMN custom_activity_id bigint REFERENCES custom_activities (id) ON DELETE SET NULL;
+
+CREATE INDEX logs_custom_activity_id_idx ON logs (custom_activity_id);
+
+ALTER SEQUENCE custom_activities_seq RESTART WITH 1;
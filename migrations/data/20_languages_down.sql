@@ -0,0 +1,43 @@
+CREATE TYPE language AS ENUM ('JA','KR','ZH','DE');
+
+DELETE FROM logs WHERE language NOT IN ('ja', 'ko', 'zh', 'de');
+
+DELETE FROM resources WHERE language NOT IN ('ja', 'ko', 'zh', 'de');
+
+DELETE FROM timers WHERE language NOT IN ('ja', 'ko', 'zh', 'de');
+
+ALTER TABLE logs DROP CONSTRAINT logs_language_fkey;
+
+ALTER TABLE logs ALTER COLUMN language TYPE language USING (CAST((CASE language WHEN 'ko' THEN 'KR' ELSE upper(language) END) AS language));
+
+UPDATE logs
+SET
+  version = version + 1,
+  updated_at = (current_timestamp AT TIME ZONE 'UTC'),
+  change_seq = NEXTVAL('logs_change_seq');
+
+ALTER TABLE resources DROP CONSTRAINT resources_language_fkey;
+
+ALTER TABLE resources ALTER COLUMN language TYPE language USING (CAST((CASE language WHEN 'ko' THEN 'KR' ELSE upper(language) END) AS language));
+
+ALTER TABLE timers DROP CONSTRAINT timers_language_fkey;
+
+ALTER TABLE timers ALTER COLUMN language TYPE language USING (CAST((CASE language WHEN 'ko' THEN 'KR' ELSE upper(language) END) AS language));
+
+UPDATE users
+SET preferences = jsonb_set(preferences, '{languages}', (
+  SELECT COALESCE(jsonb_agg(CASE value WHEN 'ko' THEN 'KR' ELSE upper(value) END), '[]')
+  FROM jsonb_array_elements_text(preferences -> 'languages')
+  WHERE value IN ('ja', 'ko', 'zh', 'de')
+))
+WHERE jsonb_typeof(preferences -> 'languages') = 'array';
+
+UPDATE log_history
+SET old_values = jsonb_set(old_values, '{language}', to_jsonb(CASE old_values ->> 'language' WHEN 'ko' THEN 'KR' ELSE upper(old_values ->> 'language') END))
+WHERE jsonb_typeof(old_values -> 'language') = 'string';
+
+UPDATE log_history
+SET new_values = jsonb_set(new_values, '{language}', to_jsonb(CASE new_values ->> 'language' WHEN 'ko' THEN 'KR' ELSE upper(new_values ->> 'language') END))
+WHERE jsonb_typeof(new_values -> 'language') = 'string';
+
+DROP TABLE languages;
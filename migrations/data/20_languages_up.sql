@@ -0,0 +1,99 @@
+CREATE TABLE languages (
+  code varchar(3) NOT NULL,
+  name varchar(100) NOT NULL,
+  native_name varchar(100) NOT NULL,
+  PRIMARY KEY (code)
+);
+
+INSERT INTO languages (code, name, native_name) VALUES
+  ('ar', 'Arabic', 'العربية'),
+  ('bn', 'Bengali', 'বাংলা'),
+  ('ca', 'Catalan', 'català'),
+  ('cs', 'Czech', 'čeština'),
+  ('cy', 'Welsh', 'Cymraeg'),
+  ('da', 'Danish', 'dansk'),
+  ('de', 'German', 'Deutsch'),
+  ('el', 'Greek', 'Ελληνικά'),
+  ('en', 'English', 'English'),
+  ('eo', 'Esperanto', 'Esperanto'),
+  ('es', 'Spanish', 'español'),
+  ('et', 'Estonian', 'eesti'),
+  ('eu', 'Basque', 'euskara'),
+  ('fa', 'Persian', 'فارسی'),
+  ('fi', 'Finnish', 'suomi'),
+  ('fr', 'French', 'français'),
+  ('ga', 'Irish', 'Gaeilge'),
+  ('he', 'Hebrew', 'עברית'),
+  ('hi', 'Hindi', 'हिन्दी'),
+  ('hr', 'Croatian', 'hrvatski'),
+  ('hu', 'Hungarian', 'magyar'),
+  ('hy', 'Armenian', 'հայերեն'),
+  ('id', 'Indonesian', 'Bahasa Indonesia'),
+  ('is', 'Icelandic', 'íslenska'),
+  ('it', 'Italian', 'italiano'),
+  ('ja', 'Japanese', '日本語'),
+  ('ka', 'Georgian', 'ქართული'),
+  ('kk', 'Kazakh', 'қазақ тілі'),
+  ('km', 'Khmer', 'ខ្មែរ'),
+  ('ko', 'Korean', '한국어'),
+  ('la', 'Latin', 'latine'),
+  ('lt', 'Lithuanian', 'lietuvių'),
+  ('lv', 'Latvian', 'latviešu'),
+  ('mn', 'Mongolian', 'монгол'),
+  ('ms', 'Malay', 'Bahasa Melayu'),
+  ('my', 'Burmese', 'မြန်မာ'),
+  ('nl', 'Dutch', 'Nederlands'),
+  ('no', 'Norwegian', 'norsk'),
+  ('pl', 'Polish', 'polski'),
+  ('pt', 'Portuguese', 'português'),
+  ('ro', 'Romanian', 'română'),
+  ('ru', 'Russian', 'русский'),
+  ('sk', 'Slovak', 'slovenčina'),
+  ('sl', 'Slovenian', 'slovenščina'),
+  ('sr', 'Serbian', 'српски'),
+  ('sv', 'Swedish', 'svenska'),
+  ('sw', 'Swahili', 'Kiswahili'),
+  ('ta', 'Tamil', 'தமிழ்'),
+  ('th', 'Thai', 'ไทย'),
+  ('tl', 'Tagalog', 'Tagalog'),
+  ('tr', 'Turkish', 'Türkçe'),
+  ('uk', 'Ukrainian', 'українська'),
+  ('ur', 'Urdu', 'اردو'),
+  ('vi', 'Vietnamese', 'Tiếng Việt'),
+  ('yue', 'Cantonese', '粵語'),
+  ('zh', 'Chinese', '中文');
+
+ALTER TABLE logs ALTER COLUMN language TYPE varchar(3) USING (CASE CAST(language AS text) WHEN 'KR' THEN 'ko' ELSE lower(CAST(language AS text)) END);
+
+ALTER TABLE logs ADD CONSTRAINT logs_language_fkey FOREIGN KEY (language) REFERENCES languages (code);
+
+UPDATE logs
+SET
+  version = version + 1,
+  updated_at = (current_timestamp AT TIME ZONE 'UTC'),
+  change_seq = NEXTVAL('logs_change_seq');
+
+ALTER TABLE resources ALTER COLUMN language TYPE varchar(3) USING (CASE CAST(language AS text) WHEN 'KR' THEN 'ko' ELSE lower(CAST(language AS text)) END);
+
+ALTER TABLE resources ADD CONSTRAINT resources_language_fkey FOREIGN KEY (language) REFERENCES languages (code);
+
+ALTER TABLE timers ALTER COLUMN language TYPE varchar(3) USING (CASE CAST(language AS text) WHEN 'KR' THEN 'ko' ELSE lower(CAST(language AS text)) END);
+
+ALTER TABLE timers ADD CONSTRAINT timers_language_fkey FOREIGN KEY (language) REFERENCES languages (code);
+
+UPDATE users
+SET preferences = jsonb_set(preferences, '{languages}', (
+  SELECT COALESCE(jsonb_agg(CASE value WHEN 'KR' THEN 'ko' ELSE lower(value) END), '[]')
+  FROM jsonb_array_elements_text(preferences -> 'languages')
+))
+WHERE jsonb_typeof(preferences -> 'languages') = 'array';
+
+UPDATE log_history
+SET old_values = jsonb_set(old_values, '{language}', to_jsonb(CASE old_values ->> 'language' WHEN 'KR' THEN 'ko' ELSE lower(old_values ->> 'language') END))
+WHERE jsonb_typeof(old_values -> 'language') = 'string';
+
+UPDATE log_history
+SET new_values = jsonb_set(new_values, '{language}', to_jsonb(CASE new_values ->> 'language' WHEN 'KR' THEN 'ko' ELSE lower(new_values ->> 'language') END))
+WHERE jsonb_typeof(new_values -> 'language') = 'string';
+
+DROP TYPE language;
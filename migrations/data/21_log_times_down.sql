@@ -0,0 +1,8 @@
+ALTER TABLE timers DROP COLUMN was_paused;
+
+DROP INDEX logs_user_id_started_at_idx;
+
+ALTER TABLE logs
+  DROP CONSTRAINT logs_ended_at_check,
+  DROP COLUMN ended_at,
+  DROP COLUMN started_at;
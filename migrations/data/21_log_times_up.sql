@@ -0,0 +1,9 @@
+ALTER TABLE logs
+  ADD COLUMN started_at timestamp,
+  ADD COLUMN ended_at timestamp,
+  ADD CONSTRAINT logs_ended_at_check CHECK (ended_at IS NULL OR (started_at IS NOT NULL AND ended_at > started_at));
+
+CREATE INDEX logs_user_id_started_at_idx ON logs (user_id, started_at) WHERE started_at IS NOT NULL;
+
+ALTER TABLE timers
+  ADD COLUMN was_paused boolean NOT NULL DEFAULT FALSE;
@@ -0,0 +1,7 @@
+DROP INDEX logs_template_id_date_idx;
+
+ALTER TABLE logs DROP COLUMN template_id;
+
+DROP TABLE log_templates;
+
+DROP SEQUENCE log_templates_seq;
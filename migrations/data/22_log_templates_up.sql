@@ -0,0 +1,26 @@
+CREATE SEQUENCE log_templates_seq;
+
+CREATE TABLE log_templates (
+  id bigint check (id > 0) NOT NULL DEFAULT NEXTVAL ('log_templates_seq'),
+  user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name varchar(50) NOT NULL,
+  language varchar(3) NOT NULL REFERENCES languages (code),
+  activity activity NOT NULL,
+  custom_activity_id bigint REFERENCES custom_activities (id) ON DELETE SET NULL,
+  duration bigint check (duration > 0) NOT NULL,
+  notes jsonb,
+  resource_id bigint REFERENCES resources (id) ON DELETE SET NULL,
+  tags text[] NOT NULL DEFAULT '{}',
+  weekdays smallint[] NOT NULL DEFAULT '{}',
+  created_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  updated_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX log_templates_user_id_name_idx ON log_templates (user_id, lower(name));
+
+ALTER TABLE logs ADD COLUMN template_id bigint REFERENCES log_templates (id) ON DELETE SET NULL;
+
+CREATE INDEX logs_template_id_date_idx ON logs (template_id, date);
+
+ALTER SEQUENCE log_templates_seq RESTART WITH 1;
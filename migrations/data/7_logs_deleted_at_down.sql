@@ -0,0 +1,3 @@
+DROP INDEX logs_deleted_at_idx;
+
+ALTER TABLE logs DROP COLUMN deleted_at;
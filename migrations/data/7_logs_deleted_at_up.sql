@@ -0,0 +1,5 @@
+ALTER TABLE logs ADD COLUMN deleted_at timestamp DEFAULT NULL;
+
+UPDATE logs SET deleted_at = (current_timestamp AT TIME ZONE 'UTC') WHERE deleted = TRUE;
+
+CREATE INDEX logs_deleted_at_idx ON logs (deleted_at) WHERE deleted = TRUE;
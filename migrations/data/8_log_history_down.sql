@@ -0,0 +1,5 @@
+DROP TABLE log_history CASCADE;
+
+DROP TYPE log_action;
+
+DROP SEQUENCE log_history_seq;
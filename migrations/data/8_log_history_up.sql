@@ -0,0 +1,18 @@
+CREATE SEQUENCE log_history_seq;
+
+CREATE TYPE log_action AS ENUM ('CREATE','UPDATE','DELETE','RESTORE');
+
+CREATE TABLE log_history (
+  id bigint check (id > 0) NOT NULL DEFAULT NEXTVAL ('log_history_seq'),
+  log_id bigint NOT NULL,
+  user_id bigint NOT NULL REFERENCES users (id),
+  action log_action NOT NULL,
+  old_values jsonb,
+  new_values jsonb,
+  created_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX log_history_log_id_idx ON log_history (log_id);
+
+ALTER SEQUENCE log_history_seq RESTART WITH 1;
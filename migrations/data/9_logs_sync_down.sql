@@ -0,0 +1,11 @@
+DROP TABLE log_tombstones;
+
+DROP INDEX logs_user_change_seq_idx;
+
+ALTER TABLE logs
+  DROP COLUMN created_at,
+  DROP COLUMN updated_at,
+  DROP COLUMN version,
+  DROP COLUMN change_seq;
+
+DROP SEQUENCE logs_change_seq;
@@ -0,0 +1,19 @@
+CREATE SEQUENCE logs_change_seq;
+
+ALTER TABLE logs
+  ADD COLUMN created_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  ADD COLUMN updated_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  ADD COLUMN version bigint check (version > 0) NOT NULL DEFAULT 1,
+  ADD COLUMN change_seq bigint NOT NULL DEFAULT NEXTVAL ('logs_change_seq');
+
+CREATE INDEX logs_user_change_seq_idx ON logs (user_id, change_seq);
+
+CREATE TABLE log_tombstones (
+  log_id bigint NOT NULL,
+  user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  change_seq bigint NOT NULL,
+  purged_at timestamp NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+  PRIMARY KEY (log_id)
+);
+
+CREATE INDEX log_tombstones_user_change_seq_idx ON log_tombstones (user_id, change_seq);
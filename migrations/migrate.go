@@ -3,7 +3,6 @@ package migrations
 import (
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/antonve/logger-api/config"
 	"github.com/antonve/logger-api/models"
@@ -12,13 +11,7 @@ import (
 )
 
 func getMigrator() (*gomigrate.Migrator, error) {
-	// @TODO: change how migration path is handled
-	appPath := os.Getenv("APP_PATH")
-	if appPath == "" {
-		appPath = fmt.Sprintf("%s/src/github.com/antonve/logger-api", os.Getenv("GOPATH"))
-	}
-
-	return gomigrate.NewMigrator(models.GetSQLDatabase(), gomigrate.Postgres{}, fmt.Sprintf("%s/%s", appPath, config.GetConfig().MigrationsPath))
+	return gomigrate.NewMigrator(models.GetSQLDatabase(), gomigrate.Postgres{}, fmt.Sprintf("%s/%s", config.GetAppPath(), config.GetConfig().MigrationsPath))
 }
 
 // Migrate migrates the database
@@ -42,7 +35,6 @@ func Destroy() error {
 	}
 
 	db := models.GetSQLConnection()
-	defer db.Close()
 
 	// Drop database
 	_, err := db.Exec("DROP DATABASE IF EXISTS " + config.GetConfig().Database)
@@ -56,7 +48,6 @@ func Destroy() error {
 // Create a new database
 func Create() error {
 	db := models.GetSQLConnection()
-	defer db.Close()
 
 	// Create database
 	_, err := db.Exec("CREATE DATABASE " + config.GetConfig().Database)
@@ -0,0 +1,272 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/jmoiron/sqlx"
+)
+
+// CustomActivityCollection array of custom activities
+type CustomActivityCollection struct {
+	Activities []CustomActivity `json:"activities"`
+}
+
+// CustomActivity model, an activity defined by a user such as `Shadowing`.
+// It belongs to one of the built-in activities which logs with the custom activity are reported under.
+type CustomActivity struct {
+	ID        uint64         `json:"id" db:"id"`
+	UserID    uint64         `json:"user_id" db:"user_id"`
+	Name      string         `json:"name" db:"name"`
+	Category  enums.Activity `json:"category" db:"category"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at" db:"updated_at"`
+
+	// Logs is the amount of logs with the activity, the trash excluded
+	Logs uint64 `json:"logs" db:"logs"`
+}
+
+// maxCustomActivityNameLength is the longest name a custom activity can have
+const maxCustomActivityNameLength = 50
+
+// customActivityColumns are the columns selected for a CustomActivity
+const customActivityColumns = `
+			id,
+			user_id,
+			name,
+			category,
+			created_at,
+			updated_at,
+			(
+				SELECT COUNT(*)
+				FROM logs
+				WHERE
+					logs.custom_activity_id = custom_activities.id AND
+					logs.deleted = FALSE
+			) AS logs`
+
+// Length returns the amount of custom activities in the collection
+func (customActivityCollection *CustomActivityCollection) Length() int {
+	return len(customActivityCollection.Activities)
+}
+
+// Validate the CustomActivity model
+func (customActivity *CustomActivity) Validate() error {
+	validationErrors := ValidationErrors{}
+
+	if customActivity.UserID == 0 {
+		validationErrors.Add("user_id", "invalid `UserID` supplied")
+	}
+	if strings.TrimSpace(customActivity.Name) == "" {
+		validationErrors.Add("name", "invalid `Name` supplied")
+	} else if len([]rune(customActivity.Name)) > maxCustomActivityNameLength {
+		validationErrors.Add("name", fmt.Sprintf("invalid `Name` supplied, can be at most %d characters", maxCustomActivityNameLength))
+	}
+	if len(customActivity.Category) == 0 || !customActivity.Category.IsValid() {
+		validationErrors.Add("category", "invalid `Category` supplied")
+	}
+
+	return validationErrors.Err()
+}
+
+// IsOwner checks the owner
+func (customActivity *CustomActivity) IsOwner(userID uint64) bool {
+	return customActivity.UserID == userID
+}
+
+// GetAllFromUser returns all custom activities from a certain user
+func (customActivityCollection *CustomActivityCollection) GetAllFromUser(userID uint64) error {
+	db := GetDatabase()
+
+	err := db.Select(&customActivityCollection.Activities, `
+		SELECT `+customActivityColumns+`
+		FROM custom_activities
+		WHERE user_id = $1
+		ORDER BY lower(name), id
+	`, userID)
+
+	return err
+}
+
+// Get a custom activity by id
+func (customActivityCollection *CustomActivityCollection) Get(id uint64) (*CustomActivity, error) {
+	db := GetDatabase()
+
+	customActivity := CustomActivity{}
+	err := db.Get(&customActivity, `
+		SELECT `+customActivityColumns+`
+		FROM custom_activities
+		WHERE id = $1
+	`, id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "activity", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &customActivity, nil
+}
+
+// Add a custom activity to the database
+func (customActivityCollection *CustomActivityCollection) Add(customActivity *CustomActivity) (uint64, error) {
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		err := checkCustomActivityName(tx, customActivity)
+		if err != nil {
+			return err
+		}
+
+		return tx.Get(&customActivity.ID, `
+			INSERT INTO custom_activities (user_id, name, category)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, customActivity.UserID, strings.TrimSpace(customActivity.Name), customActivity.Category)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return customActivity.ID, nil
+}
+
+// Update a custom activity, the category can't be changed once logs use the activity
+// since their notes might not fit the new category
+func (customActivityCollection *CustomActivityCollection) Update(customActivity *CustomActivity) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		err := checkCustomActivityName(tx, customActivity)
+		if err != nil {
+			return err
+		}
+
+		var inUse bool
+		err = tx.Get(&inUse, `
+			SELECT EXISTS (
+				SELECT 1
+				FROM logs
+				WHERE
+					custom_activity_id = $1 AND
+					activity <> $2
+			)
+		`, customActivity.ID, customActivity.Category)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			validationErrors := ValidationErrors{}
+			validationErrors.Add("category", "invalid `Category` supplied, can't be changed once logs use the activity")
+			return validationErrors
+		}
+
+		result, err := tx.Exec(`
+			UPDATE custom_activities
+			SET
+				name = $3,
+				category = $4,
+				updated_at = (current_timestamp AT TIME ZONE 'UTC')
+			WHERE
+				id = $1 AND
+				user_id = $2
+		`, customActivity.ID, customActivity.UserID, strings.TrimSpace(customActivity.Name), customActivity.Category)
+		if err != nil {
+			return err
+		}
+
+		updated, err := result.RowsAffected()
+		if err == nil && updated == 0 {
+			return &NotFoundError{Resource: "activity", ID: customActivity.ID}
+		}
+
+		return err
+	})
+}
+
+// Delete a custom activity, logs with the activity are kept under its category. changedBy is the user deleting it
+func (customActivityCollection *CustomActivityCollection) Delete(customActivity *CustomActivity, changedBy uint64) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		err := unlinkLogs(tx, "custom_activity_id", customActivity.ID, customActivity.UserID, changedBy)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(`
+			DELETE FROM custom_activities
+			WHERE
+				id = $1 AND
+				user_id = $2
+		`, customActivity.ID, customActivity.UserID)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err == nil && deleted == 0 {
+			return &NotFoundError{Resource: "activity", ID: customActivity.ID}
+		}
+
+		return err
+	})
+}
+
+// checkCustomActivityName makes sure the names of the custom activities of a user are unique regardless of case
+func checkCustomActivityName(tx *sqlx.Tx, customActivity *CustomActivity) error {
+	var exists bool
+	err := tx.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM custom_activities
+			WHERE
+				user_id = $1 AND
+				lower(name) = lower($2) AND
+				id <> $3
+		)
+	`, customActivity.UserID, strings.TrimSpace(customActivity.Name), customActivity.ID)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		validationErrors := ValidationErrors{}
+		validationErrors.Add("name", "invalid `Name` supplied, another activity already has this name")
+		return validationErrors
+	}
+
+	return nil
+}
+
+// checkCustomActivity makes sure a log only uses custom activities of its user,
+// logs with a custom activity get the activity of its category when they don't have one
+func checkCustomActivity(tx *sqlx.Tx, log *Log) error {
+	if log.CustomActivityID == nil {
+		return nil
+	}
+
+	validationErrors := ValidationErrors{}
+
+	var category enums.Activity
+	err := tx.Get(&category, `
+		SELECT category
+		FROM custom_activities
+		WHERE
+			id = $1 AND
+			user_id = $2
+	`, *log.CustomActivityID, log.UserID)
+	if err == sql.ErrNoRows {
+		validationErrors.Add("custom_activity_id", "invalid `CustomActivityID` supplied")
+		return validationErrors
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(log.Activity) == 0 {
+		log.Activity = category
+		validateNotes(log.Activity, log.Notes, &validationErrors)
+	} else if log.Activity != category {
+		validationErrors.Add("activity", fmt.Sprintf("invalid `Activity` supplied, the custom activity belongs to %s", category))
+	}
+
+	return validationErrors.Err()
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"io"
 	"log"
 
 	"github.com/jmoiron/sqlx"
@@ -24,7 +25,8 @@ func GetDatabase() *sqlx.DB {
 		return sqlxDB
 	}
 
-	sqlxDB, err := sqlx.Open("postgres", config.GetConfig().GetCompleteConnectionString())
+	var err error
+	sqlxDB, err = sqlx.Open("postgres", config.GetConfig().GetCompleteConnectionString())
 	if err != nil {
 		log.Fatalln("Couldn't connect to data store")
 
@@ -43,7 +45,8 @@ func GetSQLDatabase() *sql.DB {
 		return sqlDB
 	}
 
-	sqlDB, err := sql.Open("postgres", config.GetConfig().GetCompleteConnectionString())
+	var err error
+	sqlDB, err = sql.Open("postgres", config.GetConfig().GetCompleteConnectionString())
 	if err != nil {
 		log.Fatalln("Couldn't connect to data store")
 
@@ -62,7 +65,8 @@ func GetConnection() *sqlx.DB {
 		return sqlxConnection
 	}
 
-	sqlxConnection, err := sqlx.Open("postgres", config.GetConfig().ConnectionString)
+	var err error
+	sqlxConnection, err = sqlx.Open("postgres", config.GetConfig().ConnectionString)
 	if err != nil {
 		log.Fatalln("Couldn't connect to data store")
 
@@ -81,7 +85,8 @@ func GetSQLConnection() *sql.DB {
 		return sqlConnection
 	}
 
-	sqlConnection, err := sql.Open("postgres", config.GetConfig().ConnectionString)
+	var err error
+	sqlConnection, err = sql.Open("postgres", config.GetConfig().ConnectionString)
 	if err != nil {
 		log.Fatalln("Couldn't connect to data store")
 
@@ -93,3 +98,46 @@ func GetSQLConnection() *sql.DB {
 
 	return sqlConnection
 }
+
+// inTransaction runs fn in a transaction that is committed when fn succeeds and rolled back otherwise
+func inTransaction(fn func(tx *sqlx.Tx) error) error {
+	tx, err := GetDatabase().Beginx()
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CloseDatabase closes all pooled database connections, should only be called on shutdown
+func CloseDatabase() {
+	if sqlxDB != nil {
+		closeConnection(sqlxDB)
+		sqlxDB = nil
+	}
+	if sqlDB != nil {
+		closeConnection(sqlDB)
+		sqlDB = nil
+	}
+	if sqlxConnection != nil {
+		closeConnection(sqlxConnection)
+		sqlxConnection = nil
+	}
+	if sqlConnection != nil {
+		closeConnection(sqlConnection)
+		sqlConnection = nil
+	}
+}
+
+func closeConnection(connection io.Closer) {
+	err := connection.Close()
+	if err != nil {
+		log.Printf("Problem closing database connection: %s", err)
+	}
+}
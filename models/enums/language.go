@@ -2,56 +2,81 @@ package enums
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"regexp"
+	"strings"
 )
 
-// Language represents an language
+// Language represents a language by its ISO 639 code, e.g. `ja`.
+// Which languages are supported is kept in the `languages` table.
 type (
 	Language string
 )
 
-// Language values
+// Language values referred to in code, any supported language can be used
 const (
-	LanguageJapanese Language = "JA"
-	LanguageKorean   Language = "KR"
-	LanguageMandarin Language = "ZH"
-	LanguageGerman   Language = "DE"
+	LanguageJapanese Language = "ja"
+	LanguageKorean   Language = "ko"
+	LanguageMandarin Language = "zh"
+	LanguageGerman   Language = "de"
 )
 
+// legacyLanguages maps the codes used before languages were ISO 639 codes that don't match their lowercase version
+var legacyLanguages = map[string]Language{
+	"KR": LanguageKorean,
+}
+
+// languageCodeRegex matches ISO 639-1 and ISO 639-3 codes
+var languageCodeRegex = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// ParseLanguage turns a code sent by a client into a Language,
+// codes are case insensitive and the codes used before languages were ISO 639 codes are still accepted
+func ParseLanguage(code string) Language {
+	if language, ok := legacyLanguages[code]; ok {
+		return language
+	}
+
+	return Language(strings.ToLower(strings.TrimSpace(code)))
+}
+
+// UnmarshalJSON parses the Language with ParseLanguage
+func (language *Language) UnmarshalJSON(data []byte) error {
+	var code string
+	err := json.Unmarshal(data, &code)
+	if err != nil {
+		return err
+	}
+
+	*language = ParseLanguage(code)
+
+	return nil
+}
+
 // Scan Language value
 func (language *Language) Scan(src interface{}) error {
 	if src == nil {
 		return errors.New("This field cannot be NULL")
 	}
 
-	if stringLanguage, ok := src.([]byte); ok {
+	switch stringLanguage := src.(type) {
+	case []byte:
 		*language = Language(string(stringLanguage[:]))
-
+		return nil
+	case string:
+		*language = Language(stringLanguage)
 		return nil
 	}
 
-	return errors.New("Cannot convert enum to string")
+	return errors.New("Cannot convert language to string")
 }
 
 // Value of Language
 func (language Language) Value() (driver.Value, error) {
-	return []byte(language), nil
+	return string(language), nil
 }
 
-// IsValid Language Value
+// IsValid checks whether the Language looks like an ISO 639 code, not whether it's supported
 func (language Language) IsValid() bool {
-	if language == LanguageJapanese {
-		return true
-	}
-	if language == LanguageKorean {
-		return true
-	}
-	if language == LanguageMandarin {
-		return true
-	}
-	if language == LanguageGerman {
-		return true
-	}
-
-	return false
+	return languageCodeRegex.MatchString(string(language))
 }
@@ -0,0 +1,57 @@
+package enums
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// LogAction represents a change made to a log
+type (
+	LogAction string
+)
+
+// LogAction values
+const (
+	LogActionCreate  LogAction = "CREATE"
+	LogActionUpdate  LogAction = "UPDATE"
+	LogActionDelete  LogAction = "DELETE"
+	LogActionRestore LogAction = "RESTORE"
+)
+
+// Scan LogAction value
+func (logAction *LogAction) Scan(src interface{}) error {
+	if src == nil {
+		return errors.New("This field cannot be NULL")
+	}
+
+	if stringLogAction, ok := src.([]byte); ok {
+		*logAction = LogAction(string(stringLogAction[:]))
+
+		return nil
+	}
+
+	return errors.New("Cannot convert enum to string")
+}
+
+// Value of LogAction
+func (logAction LogAction) Value() (driver.Value, error) {
+	return []byte(logAction), nil
+}
+
+// IsValid LogAction Value
+func (logAction LogAction) IsValid() bool {
+	if logAction == LogActionCreate {
+		return true
+	}
+	if logAction == LogActionUpdate {
+		return true
+	}
+	if logAction == LogActionDelete {
+		return true
+	}
+	if logAction == LogActionRestore {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package enums
+
+// ReadingType represents what was read, only stored in the notes of a log
+type (
+	ReadingType string
+)
+
+// ReadingType values
+const (
+	ReadingTypeBook    ReadingType = "BOOK"
+	ReadingTypeManga   ReadingType = "MANGA"
+	ReadingTypeArticle ReadingType = "ARTICLE"
+	ReadingTypeNews    ReadingType = "NEWS"
+	ReadingTypeWeb     ReadingType = "WEB"
+	ReadingTypeOther   ReadingType = "OTHER"
+)
+
+// IsValid ReadingType Value
+func (readingType ReadingType) IsValid() bool {
+	if readingType == ReadingTypeBook {
+		return true
+	}
+	if readingType == ReadingTypeManga {
+		return true
+	}
+	if readingType == ReadingTypeArticle {
+		return true
+	}
+	if readingType == ReadingTypeNews {
+		return true
+	}
+	if readingType == ReadingTypeWeb {
+		return true
+	}
+	if readingType == ReadingTypeOther {
+		return true
+	}
+
+	return false
+}
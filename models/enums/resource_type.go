@@ -0,0 +1,65 @@
+package enums
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// ResourceType represents the kind of learning resource
+type (
+	ResourceType string
+)
+
+// ResourceType values
+const (
+	ResourceTypeBook     ResourceType = "BOOK"
+	ResourceTypeShow     ResourceType = "SHOW"
+	ResourceTypePodcast  ResourceType = "PODCAST"
+	ResourceTypeDeck     ResourceType = "DECK"
+	ResourceTypeTextbook ResourceType = "TEXTBOOK"
+	ResourceTypeOther    ResourceType = "OTHER"
+)
+
+// Scan ResourceType value
+func (resourceType *ResourceType) Scan(src interface{}) error {
+	if src == nil {
+		return errors.New("This field cannot be NULL")
+	}
+
+	if stringResourceType, ok := src.([]byte); ok {
+		*resourceType = ResourceType(string(stringResourceType[:]))
+
+		return nil
+	}
+
+	return errors.New("Cannot convert enum to string")
+}
+
+// Value of ResourceType
+func (resourceType ResourceType) Value() (driver.Value, error) {
+	return []byte(resourceType), nil
+}
+
+// IsValid ResourceType Value
+func (resourceType ResourceType) IsValid() bool {
+	if resourceType == ResourceTypeBook {
+		return true
+	}
+	if resourceType == ResourceTypeShow {
+		return true
+	}
+	if resourceType == ResourceTypePodcast {
+		return true
+	}
+	if resourceType == ResourceTypeDeck {
+		return true
+	}
+	if resourceType == ResourceTypeTextbook {
+		return true
+	}
+	if resourceType == ResourceTypeOther {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package enums
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// TimerMode represents the way a timer is run
+type (
+	TimerMode string
+)
+
+// TimerMode values
+const (
+	TimerModeStandard TimerMode = "STANDARD"
+	TimerModePomodoro TimerMode = "POMODORO"
+)
+
+// Scan TimerMode value
+func (timerMode *TimerMode) Scan(src interface{}) error {
+	if src == nil {
+		return errors.New("This field cannot be NULL")
+	}
+
+	if stringTimerMode, ok := src.([]byte); ok {
+		*timerMode = TimerMode(string(stringTimerMode[:]))
+
+		return nil
+	}
+
+	return errors.New("Cannot convert enum to string")
+}
+
+// Value of TimerMode
+func (timerMode TimerMode) Value() (driver.Value, error) {
+	return []byte(timerMode), nil
+}
+
+// IsValid TimerMode Value
+func (timerMode TimerMode) IsValid() bool {
+	if timerMode == TimerModeStandard {
+		return true
+	}
+	if timerMode == TimerModePomodoro {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package enums
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// TimerPhase represents whether a pomodoro timer is in a focus interval or on a break
+type (
+	TimerPhase string
+)
+
+// TimerPhase values
+const (
+	TimerPhaseFocus TimerPhase = "FOCUS"
+	TimerPhaseBreak TimerPhase = "BREAK"
+)
+
+// Scan TimerPhase value
+func (timerPhase *TimerPhase) Scan(src interface{}) error {
+	if src == nil {
+		return errors.New("This field cannot be NULL")
+	}
+
+	if stringTimerPhase, ok := src.([]byte); ok {
+		*timerPhase = TimerPhase(string(stringTimerPhase[:]))
+
+		return nil
+	}
+
+	return errors.New("Cannot convert enum to string")
+}
+
+// Value of TimerPhase
+func (timerPhase TimerPhase) Value() (driver.Value, error) {
+	return []byte(timerPhase), nil
+}
+
+// IsValid TimerPhase Value
+func (timerPhase TimerPhase) IsValid() bool {
+	if timerPhase == TimerPhaseFocus {
+		return true
+	}
+	if timerPhase == TimerPhaseBreak {
+		return true
+	}
+
+	return false
+}
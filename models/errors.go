@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes why a single field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned when a model fails validation
+type ValidationErrors []FieldError
+
+// Add a failure for a field
+func (validationErrors *ValidationErrors) Add(field string, message string) {
+	*validationErrors = append(*validationErrors, FieldError{Field: field, Message: message})
+}
+
+// Err returns nil when no failures were collected so it can be returned as an error
+func (validationErrors ValidationErrors) Err() error {
+	if len(validationErrors) == 0 {
+		return nil
+	}
+
+	return validationErrors
+}
+
+// Error joins all field errors into a single message
+func (validationErrors ValidationErrors) Error() string {
+	messages := make([]string, len(validationErrors))
+	for key, fieldError := range validationErrors {
+		messages[key] = fmt.Sprintf("`%s`: %s", fieldError.Field, fieldError.Message)
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// NotFoundError is returned when a record doesn't exist or isn't accessible
+type NotFoundError struct {
+	Resource string
+	ID       uint64
+}
+
+// Error message for a missing record
+func (notFoundError *NotFoundError) Error() string {
+	return fmt.Sprintf("no %s found with id %v", notFoundError.Resource, notFoundError.ID)
+}
+
+// VersionConflictError is returned when a record was changed since the version a client based its changes on
+type VersionConflictError struct {
+	Resource       string
+	ID             uint64
+	Version        uint64
+	CurrentVersion uint64
+}
+
+// Error message for a stale write
+func (versionConflictError *VersionConflictError) Error() string {
+	return fmt.Sprintf(
+		"%s with id %v was changed, expected version %v but found version %v",
+		versionConflictError.Resource,
+		versionConflictError.ID,
+		versionConflictError.Version,
+		versionConflictError.CurrentVersion,
+	)
+}
+
+// LogOverlapError is returned when the session of a log overlaps with the session of another log of the same user
+type LogOverlapError struct {
+	ID            uint64
+	OverlappingID uint64
+}
+
+// Error message for an overlapping session
+func (logOverlapError *LogOverlapError) Error() string {
+	return fmt.Sprintf("log overlaps with log %v, sessions can't overlap", logOverlapError.OverlappingID)
+}
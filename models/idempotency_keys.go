@@ -0,0 +1,159 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// IdempotencyKeyCollection manages the idempotency keys sent along with mutating requests
+type IdempotencyKeyCollection struct{}
+
+// IdempotencyKey model, the response to the first request made with a key is stored
+// so retries of that request can be answered without applying it again
+type IdempotencyKey struct {
+	UserID      uint64    `db:"user_id"`
+	Key         string    `db:"key"`
+	Method      string    `db:"method"`
+	Path        string    `db:"path"`
+	RequestHash string    `db:"request_hash"`
+	CreatedAt   time.Time `db:"created_at"`
+
+	// Only set once the request finished
+	StatusCode   *int    `db:"status_code"`
+	ContentType  *string `db:"content_type"`
+	ResponseBody []byte  `db:"response_body"`
+
+	// ResponseHeaders are the headers of the response that are replayed along with its body
+	ResponseHeaders types.JSONText `db:"response_headers"`
+}
+
+// MaxIdempotencyKeyLength is the longest key a client can send
+const MaxIdempotencyKeyLength = 255
+
+// IsCompleted checks whether a response was stored for the key
+func (idempotencyKey *IdempotencyKey) IsCompleted() bool {
+	return idempotencyKey.StatusCode != nil
+}
+
+// Matches checks whether the key is being reused for the same request
+func (idempotencyKey *IdempotencyKey) Matches(other *IdempotencyKey) bool {
+	return idempotencyKey.Method == other.Method &&
+		idempotencyKey.Path == other.Path &&
+		idempotencyKey.RequestHash == other.RequestHash
+}
+
+// Reserve claims a key for a request, when the key was already used within the ttl the existing key is returned instead
+func (idempotencyKeyCollection *IdempotencyKeyCollection) Reserve(idempotencyKey *IdempotencyKey, ttl time.Duration) (*IdempotencyKey, error) {
+	var existingKey *IdempotencyKey
+
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		// Expired keys can be used again
+		_, err := tx.Exec(`
+			DELETE FROM idempotency_keys
+			WHERE
+				user_id = $1 AND
+				key = $2 AND
+				created_at < (current_timestamp AT TIME ZONE 'UTC') - $3 * INTERVAL '1 second'
+		`, idempotencyKey.UserID, idempotencyKey.Key, ttl.Seconds())
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.NamedExec(`
+			INSERT INTO idempotency_keys (user_id, key, method, path, request_hash)
+			VALUES (:user_id, :key, :method, :path, :request_hash)
+			ON CONFLICT (user_id, key) DO NOTHING
+		`, idempotencyKey)
+		if err != nil {
+			return err
+		}
+
+		reserved, err := result.RowsAffected()
+		if err != nil || reserved == 1 {
+			return err
+		}
+
+		existingKey = &IdempotencyKey{}
+		err = tx.Get(existingKey, `
+			SELECT
+				user_id,
+				key,
+				method,
+				path,
+				request_hash,
+				created_at,
+				status_code,
+				content_type,
+				response_body,
+				response_headers
+			FROM idempotency_keys
+			WHERE
+				user_id = $1 AND
+				key = $2
+		`, idempotencyKey.UserID, idempotencyKey.Key)
+		if err == sql.ErrNoRows {
+			// The request holding the key released it in the meantime, treat it as still in progress so the client retries
+			*existingKey = *idempotencyKey
+			return nil
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existingKey, nil
+}
+
+// Complete stores the response to the request a key was reserved for
+func (idempotencyKeyCollection *IdempotencyKeyCollection) Complete(idempotencyKey *IdempotencyKey) error {
+	db := GetDatabase()
+
+	_, err := db.NamedExec(`
+		UPDATE idempotency_keys
+		SET
+			status_code = :status_code,
+			content_type = :content_type,
+			response_body = :response_body,
+			response_headers = :response_headers
+		WHERE
+			user_id = :user_id AND
+			key = :key
+	`, idempotencyKey)
+
+	return err
+}
+
+// Release frees a key of a request that didn't finish so it can be retried
+func (idempotencyKeyCollection *IdempotencyKeyCollection) Release(idempotencyKey *IdempotencyKey) error {
+	db := GetDatabase()
+
+	_, err := db.NamedExec(`
+		DELETE FROM idempotency_keys
+		WHERE
+			user_id = :user_id AND
+			key = :key AND
+			status_code IS NULL
+	`, idempotencyKey)
+
+	return err
+}
+
+// PurgeExpired removes keys older than the ttl
+func (idempotencyKeyCollection *IdempotencyKeyCollection) PurgeExpired(ttl time.Duration) (int64, error) {
+	db := GetDatabase()
+
+	result, err := db.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE created_at < (current_timestamp AT TIME ZONE 'UTC') - $1 * INTERVAL '1 second'
+	`, ttl.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
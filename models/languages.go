@@ -0,0 +1,72 @@
+package models
+
+import (
+	"sync"
+
+	"github.com/antonve/logger-api/models/enums"
+)
+
+// SupportedLanguageCollection array of supported languages
+type SupportedLanguageCollection struct {
+	Languages []SupportedLanguage `json:"languages"`
+}
+
+// SupportedLanguage model, a language users can log their studies for
+type SupportedLanguage struct {
+	Code       enums.Language `json:"code" db:"code"`
+	Name       string         `json:"name" db:"name"`
+	NativeName string         `json:"native_name" db:"native_name"`
+}
+
+// supportedLanguageCodes caches the codes in the `languages` table, they only change with migrations
+var (
+	supportedLanguageCodes      map[enums.Language]bool
+	supportedLanguageCodesMutex sync.Mutex
+)
+
+// Length returns the amount of languages in the collection
+func (supportedLanguageCollection *SupportedLanguageCollection) Length() int {
+	return len(supportedLanguageCollection.Languages)
+}
+
+// GetAll returns all supported languages ordered by their English name
+func (supportedLanguageCollection *SupportedLanguageCollection) GetAll() error {
+	db := GetDatabase()
+
+	err := db.Select(&supportedLanguageCollection.Languages, `
+		SELECT
+			code,
+			name,
+			native_name
+		FROM languages
+		ORDER BY name
+	`)
+
+	return err
+}
+
+// isSupportedLanguage checks whether a language is in the `languages` table.
+// Only the format of the code is checked when the table can't be read, the database still rejects unknown languages.
+func isSupportedLanguage(language enums.Language) bool {
+	if !language.IsValid() {
+		return false
+	}
+
+	supportedLanguageCodesMutex.Lock()
+	defer supportedLanguageCodesMutex.Unlock()
+
+	if supportedLanguageCodes == nil {
+		supportedLanguageCollection := SupportedLanguageCollection{}
+		err := supportedLanguageCollection.GetAll()
+		if err != nil {
+			return true
+		}
+
+		supportedLanguageCodes = make(map[enums.Language]bool)
+		for _, supportedLanguage := range supportedLanguageCollection.Languages {
+			supportedLanguageCodes[supportedLanguage.Code] = true
+		}
+	}
+
+	return supportedLanguageCodes[language]
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/jmoiron/sqlx"
+)
+
+// MaxLogOperations is the maximum amount of operations in a single batch
+const MaxLogOperations = 100
+
+// ErrLogBatchFailed is returned when at least one operation of a batch failed and nothing was saved,
+// other errors mean the batch couldn't be saved as a whole
+var ErrLogBatchFailed = errors.New("log batch failed, no operations were applied")
+
+// LogOperation model, a single create, update or delete in a batch of operations
+type LogOperation struct {
+	Action enums.LogAction `json:"action"`
+	ID     uint64          `json:"id"`
+	Log    *Log            `json:"log"`
+
+	// Version is the version of the log a delete is based on, it's only checked when it's set
+	Version uint64 `json:"version"`
+}
+
+// LogOperationResult is the outcome of a single operation in a batch
+type LogOperationResult struct {
+	Index  int             `json:"index"`
+	Action enums.LogAction `json:"action"`
+	ID     uint64          `json:"id,omitempty"`
+	Log    *Log            `json:"log,omitempty"`
+
+	// Err is set for the operations that failed
+	Err error `json:"-"`
+	// Applied is only true when the whole batch was saved
+	Applied bool `json:"-"`
+}
+
+// Validate the LogOperation model, the log has to belong to the user making the batch already
+func (logOperation *LogOperation) Validate() error {
+	validationErrors := ValidationErrors{}
+
+	switch logOperation.Action {
+	case enums.LogActionCreate:
+		if logOperation.Log == nil {
+			validationErrors.Add("log", "no `Log` supplied")
+		}
+	case enums.LogActionUpdate:
+		if logOperation.ID == 0 {
+			validationErrors.Add("id", "invalid `ID` supplied")
+		}
+		if logOperation.Log == nil {
+			validationErrors.Add("log", "no `Log` supplied")
+		}
+	case enums.LogActionDelete:
+		if logOperation.ID == 0 {
+			validationErrors.Add("id", "invalid `ID` supplied")
+		}
+	default:
+		validationErrors.Add("action", "invalid `Action` supplied, must be one of CREATE, UPDATE or DELETE")
+	}
+
+	// Validate the log itself, its fields are reported as part of this operation
+	if logOperation.Log != nil && logOperation.Action != enums.LogActionDelete {
+		if err := logOperation.Log.Validate(); err != nil {
+			for _, fieldError := range err.(ValidationErrors) {
+				validationErrors.Add(fmt.Sprintf("log.%s", fieldError.Field), fieldError.Message)
+			}
+		}
+	}
+
+	return validationErrors.Err()
+}
+
+// Batch applies a list of operations on logs of a user in a single transaction,
+// either all of them are saved or none are
+func (logCollection *LogCollection) Batch(userID uint64, logOperations []LogOperation) ([]LogOperationResult, error) {
+	results := make([]LogOperationResult, len(logOperations))
+
+	// Don't bother starting a transaction when we already know it will fail
+	failed := false
+	for index := range logOperations {
+		logOperation := &logOperations[index]
+		results[index] = LogOperationResult{Index: index, Action: logOperation.Action, ID: logOperation.ID}
+
+		// Operations can only change logs of the user making the batch
+		if logOperation.Log != nil {
+			logOperation.Log.ID = logOperation.ID
+			logOperation.Log.UserID = userID
+		}
+
+		err := logOperation.Validate()
+		if err != nil {
+			results[index].Err = err
+			failed = true
+		}
+	}
+	if failed {
+		return results, ErrLogBatchFailed
+	}
+
+	operationFailed := false
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		for index := range logOperations {
+			err := applyLogOperation(tx, userID, &logOperations[index], &results[index])
+			if err != nil {
+				results[index].Err = err
+				operationFailed = true
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		// Nothing was saved, don't hand out logs that were rolled back or IDs of logs that were never created
+		for index := range results {
+			if results[index].Action == enums.LogActionCreate {
+				results[index].ID = 0
+			}
+			results[index].Log = nil
+			results[index].Applied = false
+		}
+
+		// The transaction itself failed when none of the operations did
+		if !operationFailed {
+			return results, err
+		}
+
+		return results, ErrLogBatchFailed
+	}
+
+	for index := range results {
+		results[index].Applied = true
+	}
+
+	return results, nil
+}
+
+func applyLogOperation(tx *sqlx.Tx, userID uint64, logOperation *LogOperation, result *LogOperationResult) error {
+	var err error
+
+	switch logOperation.Action {
+	case enums.LogActionCreate:
+		result.Log, err = addLog(tx, logOperation.Log, userID)
+	case enums.LogActionUpdate:
+		result.Log, err = updateLog(tx, logOperation.Log, userID)
+	case enums.LogActionDelete:
+		err = deleteLog(tx, &Log{ID: logOperation.ID, UserID: userID, Version: logOperation.Version}, userID)
+	}
+
+	if result.Log != nil {
+		result.ID = result.Log.ID
+	}
+
+	return err
+}
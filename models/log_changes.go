@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MaxLogChanges is the maximum amount of changes returned at once
+const MaxLogChanges = 500
+
+// LogChangeCollection is a page of the change feed of a user's logs
+type LogChangeCollection struct {
+	Changes []LogChange `json:"changes"`
+
+	// PurgedIDs are the logs that were purged from the trash, clients remove them like deleted logs
+	PurgedIDs []uint64 `json:"purged_ids"`
+
+	// NextCursor is passed as `since` to get the changes that happened after this page
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// LogChange is the latest state of a log that was created, updated, deleted or restored.
+// Deleted logs are sent as tombstones so clients can remove their local copy.
+type LogChange struct {
+	Log
+	Deleted bool `json:"deleted" db:"deleted"`
+
+	ChangeSeq uint64 `json:"-" db:"change_seq"`
+}
+
+// logTombstone is what's left of a log after it was purged from the trash
+type logTombstone struct {
+	LogID     uint64 `db:"log_id"`
+	ChangeSeq uint64 `db:"change_seq"`
+}
+
+// ParseLogCursor parses a cursor handed out by the change feed, an empty cursor starts from the beginning
+func ParseLogCursor(cursor string) (uint64, bool) {
+	if cursor == "" {
+		return 0, true
+	}
+
+	since, err := strconv.ParseUint(cursor, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return since, true
+}
+
+// GetFromUser returns the changes to logs of a user since a cursor, oldest first.
+// Logs that were purged from the trash are only sent as their id in PurgedIDs.
+func (logChangeCollection *LogChangeCollection) GetFromUser(userID uint64, since uint64, limit int) error {
+	if limit <= 0 || limit > MaxLogChanges {
+		limit = MaxLogChanges
+	}
+
+	// Read logs and tombstones from the same snapshot so a purge in between doesn't show up twice
+	tx, err := GetDatabase().BeginTxx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Fetch one extra change of both to know whether there's more to come
+	changes := make([]LogChange, 0)
+	err = tx.Select(&changes, `
+		SELECT `+logColumns+`,
+			deleted,
+			deleted_at,
+			change_seq
+		FROM logs
+		WHERE
+			user_id = $1 AND
+			change_seq > $2
+		ORDER BY change_seq
+		LIMIT $3
+	`, userID, since, limit+1)
+	if err != nil {
+		return err
+	}
+
+	tombstones := make([]logTombstone, 0)
+	err = tx.Select(&tombstones, `
+		SELECT
+			log_id,
+			change_seq
+		FROM log_tombstones
+		WHERE
+			user_id = $1 AND
+			change_seq > $2
+		ORDER BY change_seq
+		LIMIT $3
+	`, userID, since, limit+1)
+	if err != nil {
+		return err
+	}
+
+	// Merge both by change_seq until the page is full
+	logChangeCollection.Changes = make([]LogChange, 0)
+	logChangeCollection.PurgedIDs = make([]uint64, 0)
+	for len(logChangeCollection.Changes)+len(logChangeCollection.PurgedIDs) < limit && (len(changes) > 0 || len(tombstones) > 0) {
+		if len(tombstones) == 0 || (len(changes) > 0 && changes[0].ChangeSeq < tombstones[0].ChangeSeq) {
+			logChangeCollection.Changes = append(logChangeCollection.Changes, changes[0])
+			since = changes[0].ChangeSeq
+			changes = changes[1:]
+		} else {
+			logChangeCollection.PurgedIDs = append(logChangeCollection.PurgedIDs, tombstones[0].LogID)
+			since = tombstones[0].ChangeSeq
+			tombstones = tombstones[1:]
+		}
+	}
+	logChangeCollection.HasMore = len(changes) > 0 || len(tombstones) > 0
+
+	// Clients keep their cursor when nothing changed
+	logChangeCollection.NextCursor = strconv.FormatUint(since, 10)
+
+	return nil
+}
+
+// lockLogChanges makes changes to the logs of a user wait for each other until the transaction ends.
+// Every change_seq is assigned while holding it, so the changes of a user are committed in the order of
+// their change_seq and the feed can't move past a change that wasn't committed yet.
+func lockLogChanges(tx *sqlx.Tx, userID uint64) error {
+	_, err := tx.Exec(`SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE`, userID)
+
+	return err
+}
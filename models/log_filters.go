@@ -0,0 +1,241 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/lib/pq"
+)
+
+// LogFilters narrows down and orders the logs of a user
+type LogFilters struct {
+	UserID      uint64
+	Date        string
+	From        string
+	Until       string
+	Languages   []enums.Language
+	Activities  []enums.Activity
+	MinDuration uint64
+	MaxDuration uint64
+	ResourceID  uint64
+
+	// CustomActivityID matches logs with this custom activity
+	CustomActivityID uint64
+
+	// Tags matches logs with any of these tags
+	Tags []string
+
+	// Notes matches fields in the notes of a log, e.g. `type` => `BOOK`
+	Notes map[string]string
+
+	// Sort is a list of fields, prefixed with `-` to sort descending.
+	// Logs are always grouped by date so pages can be cut by date, the other fields order logs within a date.
+	Sort []string
+}
+
+// DefaultLogSort is used when no sort fields are given
+var DefaultLogSort = []string{"-date", "language", "id"}
+
+// logSortColumns maps the fields logs can be sorted by onto their column
+var logSortColumns = map[string]string{
+	"date":       "date",
+	"duration":   "duration",
+	"language":   "language",
+	"activity":   "activity",
+	"created_at": "created_at",
+	"started_at": "started_at",
+	"id":         "id",
+}
+
+// notesKeyRegex restricts the fields of notes that can be filtered on
+var notesKeyRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,63}$`)
+
+// Validate the LogFilters model
+func (logFilters *LogFilters) Validate() error {
+	validationErrors := ValidationErrors{}
+
+	dates := []struct{ field, date string }{
+		{"date", logFilters.Date},
+		{"from", logFilters.From},
+		{"until", logFilters.Until},
+	}
+	for _, date := range dates {
+		if date.date == "" {
+			continue
+		}
+		if _, err := time.Parse(DateFormat, date.date); err != nil {
+			validationErrors.Add(date.field, fmt.Sprintf("invalid `%s` supplied, expected format YYYY-MM-DD", date.field))
+		}
+	}
+	for _, language := range logFilters.Languages {
+		if !isSupportedLanguage(language) {
+			validationErrors.Add("language", fmt.Sprintf("invalid `Language` %s supplied", language))
+		}
+	}
+	for _, activity := range logFilters.Activities {
+		if !activity.IsValid() {
+			validationErrors.Add("activity", fmt.Sprintf("invalid `Activity` %s supplied", activity))
+		}
+	}
+	if logFilters.MaxDuration != 0 && logFilters.MinDuration > logFilters.MaxDuration {
+		validationErrors.Add("min_duration", "`MinDuration` can't be larger than `MaxDuration`")
+	}
+	for _, key := range logFilters.notesKeys() {
+		if !notesKeyRegex.MatchString(key) {
+			validationErrors.Add("notes."+key, "invalid notes field supplied")
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range logFilters.Sort {
+		column := strings.TrimPrefix(field, "-")
+		if _, ok := logSortColumns[column]; !ok {
+			validationErrors.Add("sort", fmt.Sprintf("can't sort by `%s`", field))
+		} else if seen[column] {
+			validationErrors.Add("sort", fmt.Sprintf("can't sort by `%s` more than once", column))
+		}
+		seen[column] = true
+	}
+
+	return validationErrors.Err()
+}
+
+// where builds the conditions of the filters along with their arguments
+func (logFilters *LogFilters) where() (string, map[string]interface{}) {
+	conditions := []string{"deleted = FALSE"}
+	args := make(map[string]interface{})
+
+	if logFilters.UserID != 0 {
+		conditions = append(conditions, "user_id = :user_id")
+		args["user_id"] = logFilters.UserID
+	}
+	if logFilters.Date != "" {
+		conditions = append(conditions, "date = :date")
+		args["date"] = logFilters.Date
+	}
+	if logFilters.From != "" {
+		conditions = append(conditions, "date >= :from")
+		args["from"] = logFilters.From
+	}
+	if logFilters.Until != "" {
+		conditions = append(conditions, "date <= :until")
+		args["until"] = logFilters.Until
+	}
+	if len(logFilters.Languages) > 0 {
+		languages := make([]string, len(logFilters.Languages))
+		for key, language := range logFilters.Languages {
+			languages[key] = string(language)
+		}
+
+		conditions = append(conditions, "CAST(language AS text) = ANY(:languages)")
+		args["languages"] = pq.Array(languages)
+	}
+	if len(logFilters.Activities) > 0 {
+		activities := make([]string, len(logFilters.Activities))
+		for key, activity := range logFilters.Activities {
+			activities[key] = string(activity)
+		}
+
+		conditions = append(conditions, "CAST(activity AS text) = ANY(:activities)")
+		args["activities"] = pq.Array(activities)
+	}
+	if logFilters.MinDuration != 0 {
+		conditions = append(conditions, "duration >= :min_duration")
+		args["min_duration"] = logFilters.MinDuration
+	}
+	if logFilters.MaxDuration != 0 {
+		conditions = append(conditions, "duration <= :max_duration")
+		args["max_duration"] = logFilters.MaxDuration
+	}
+
+	if logFilters.ResourceID != 0 {
+		conditions = append(conditions, "resource_id = :resource_id")
+		args["resource_id"] = logFilters.ResourceID
+	}
+
+	if logFilters.CustomActivityID != 0 {
+		conditions = append(conditions, "custom_activity_id = :custom_activity_id")
+		args["custom_activity_id"] = logFilters.CustomActivityID
+	}
+
+	if len(logFilters.Tags) > 0 {
+		condition, tags := tagsCondition(logFilters.Tags)
+		conditions = append(conditions, condition)
+		args["tags"] = tags
+	}
+
+	for index, key := range logFilters.notesKeys() {
+		conditions = append(conditions, fmt.Sprintf("notes ->> :notes_key_%d = :notes_value_%d", index, index))
+		args[fmt.Sprintf("notes_key_%d", index)] = key
+		args[fmt.Sprintf("notes_value_%d", index)] = logFilters.Notes[key]
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// isDateDescending checks which way logs are grouped by date
+func (logFilters *LogFilters) isDateDescending() bool {
+	for _, field := range logFilters.sortFields() {
+		if strings.TrimPrefix(field, "-") == "date" {
+			return strings.HasPrefix(field, "-")
+		}
+	}
+
+	return true
+}
+
+// orderBy builds the ORDER BY clause, date always comes first
+func (logFilters *LogFilters) orderBy() string {
+	direction := "ASC"
+	if logFilters.isDateDescending() {
+		direction = "DESC"
+	}
+	order := []string{"date " + direction}
+
+	hasID := false
+	for _, field := range logFilters.sortFields() {
+		column := strings.TrimPrefix(field, "-")
+		if column == "date" {
+			continue
+		}
+		hasID = hasID || column == "id"
+
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+		}
+		order = append(order, fmt.Sprintf("%s %s", logSortColumns[column], direction))
+	}
+
+	// Make sure the order is always the same
+	if !hasID {
+		order = append(order, "id ASC")
+	}
+
+	return strings.Join(order, ", ")
+}
+
+// sortFields returns the fields to sort by, falls back to the default order
+func (logFilters *LogFilters) sortFields() []string {
+	if len(logFilters.Sort) == 0 {
+		return DefaultLogSort
+	}
+
+	return logFilters.Sort
+}
+
+// notesKeys returns the notes fields that are filtered on in a fixed order,
+// so the same filters always result in the same query
+func (logFilters *LogFilters) notesKeys() []string {
+	keys := make([]string, 0, len(logFilters.Notes))
+	for key := range logFilters.Notes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
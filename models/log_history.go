@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// LogHistoryCollection array of changes made to a log
+type LogHistoryCollection struct {
+	History []LogHistory `json:"history"`
+}
+
+// LogHistory model, records a single change made to a log
+type LogHistory struct {
+	ID    uint64 `json:"id" db:"id"`
+	LogID uint64 `json:"log_id" db:"log_id"`
+
+	// UserID is the user who made the change
+	UserID    uint64          `json:"user_id" db:"user_id"`
+	Action    enums.LogAction `json:"action" db:"action"`
+	OldValues *types.JSONText `json:"old_values" db:"old_values"`
+	NewValues *types.JSONText `json:"new_values" db:"new_values"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Length returns the amount of changes in the collection
+func (logHistoryCollection *LogHistoryCollection) Length() int {
+	return len(logHistoryCollection.History)
+}
+
+// GetByLog returns all changes made to a log of a user, oldest first
+func (logHistoryCollection *LogHistoryCollection) GetByLog(logID uint64, userID uint64) error {
+	db := GetDatabase()
+
+	// The history of purged logs is kept, but it's no longer served
+	var exists bool
+	err := db.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM logs
+			WHERE
+				id = $1 AND
+				user_id = $2
+		)
+	`, logID, userID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &NotFoundError{Resource: "log", ID: logID}
+	}
+
+	err = db.Select(&logHistoryCollection.History, `
+		SELECT
+			id,
+			log_id,
+			user_id,
+			action,
+			old_values,
+			new_values,
+			created_at
+		FROM log_history
+		WHERE log_id = $1
+		ORDER BY created_at, id
+	`, logID)
+
+	return err
+}
+
+// addLogHistory records a change to a log made by changedBy, must be done in the same transaction as the change itself
+func addLogHistory(tx *sqlx.Tx, changedBy uint64, action enums.LogAction, oldLog *Log, newLog *Log) error {
+	logHistory := LogHistory{UserID: changedBy, Action: action}
+
+	if oldLog != nil {
+		logHistory.LogID = oldLog.ID
+		oldValues, err := json.Marshal(oldLog)
+		if err != nil {
+			return err
+		}
+		logHistory.OldValues = (*types.JSONText)(&oldValues)
+	}
+	if newLog != nil {
+		logHistory.LogID = newLog.ID
+		newValues, err := json.Marshal(newLog)
+		if err != nil {
+			return err
+		}
+		logHistory.NewValues = (*types.JSONText)(&newValues)
+	}
+
+	_, err := tx.NamedExec(`
+		INSERT INTO log_history (log_id, user_id, action, old_values, new_values)
+		VALUES (:log_id, :user_id, :action, :old_values, :new_values)
+	`, logHistory)
+
+	return err
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultDuplicateWindow is the amount of minutes within which identical logs are suspected duplicates when no window is given
+const DefaultDuplicateWindow = 10
+
+// MaxDuplicateWindow is the largest window in minutes to look for duplicates in
+const MaxDuplicateWindow = 24 * 60
+
+// LogDuplicate is a log that's suspected to be a duplicate of a log created shortly before it
+// with the same date, language, activity and duration
+type LogDuplicate struct {
+	Log
+
+	// DuplicateOf is the id of the earliest log it's a duplicate of
+	DuplicateOf uint64 `json:"duplicate_of" db:"duplicate_of"`
+}
+
+// LogDuplicateCollection array of suspected duplicates
+type LogDuplicateCollection struct {
+	Window     uint64         `json:"window"`
+	Duplicates []LogDuplicate `json:"duplicates"`
+}
+
+// logSessionEndExpression is when the session of a log ended, logs without an end time are assumed to last their duration
+const logSessionEndExpression = `COALESCE(ended_at, started_at + duration * interval '1 minute')`
+
+// Length returns the amount of duplicates in the collection
+func (logDuplicateCollection *LogDuplicateCollection) Length() int {
+	return len(logDuplicateCollection.Duplicates)
+}
+
+// GetFromUser returns the logs of a user that are suspected duplicates, window is the amount of minutes
+// between the creation of two identical logs for them to count as duplicates
+func (logDuplicateCollection *LogDuplicateCollection) GetFromUser(userID uint64, window uint64) error {
+	db := GetDatabase()
+
+	if window == 0 || window > MaxDuplicateWindow {
+		validationErrors := ValidationErrors{}
+		validationErrors.Add("window", fmt.Sprintf("invalid `Window` supplied, must be between 1 and %d minutes", MaxDuplicateWindow))
+		return validationErrors
+	}
+	logDuplicateCollection.Window = window
+
+	err := db.Select(&logDuplicateCollection.Duplicates, `
+		SELECT *
+		FROM (
+			SELECT `+logColumns+`,
+				(
+					SELECT original.id
+					FROM logs AS original
+					WHERE
+						original.user_id = logs.user_id AND
+						original.deleted = FALSE AND
+						original.date = logs.date AND
+						original.language = logs.language AND
+						original.activity = logs.activity AND
+						original.duration = logs.duration AND
+						(original.created_at, original.id) < (logs.created_at, logs.id) AND
+						original.created_at >= logs.created_at - CAST($2 AS integer) * interval '1 minute'
+					ORDER BY original.created_at, original.id
+					LIMIT 1
+				) AS duplicate_of
+			FROM logs
+			WHERE
+				user_id = $1 AND
+				deleted = FALSE
+		) AS logs
+		WHERE duplicate_of IS NOT NULL
+		ORDER BY date DESC, created_at, id
+	`, userID, window)
+
+	return err
+}
+
+// checkLogOverlap makes sure the session of a log doesn't overlap with another log of its user,
+// only logs with a start time are checked. oldLog is the log as it was before an update, nil for new logs.
+// The caller holds lockLogChanges, so logs saved at the same time can't overlap with each other either.
+func checkLogOverlap(tx *sqlx.Tx, log *Log, oldLog *Log) error {
+	if log.StartedAt == nil {
+		return nil
+	}
+	// Logs that already overlapped before overlaps were checked can still be changed as long as their times stay the same
+	if oldLog != nil && isSameTime(log.StartedAt, oldLog.StartedAt) && isSameTime(log.EndedAt, oldLog.EndedAt) && log.Duration == oldLog.Duration {
+		return nil
+	}
+
+	endedAt := log.StartedAt.Add(time.Duration(log.Duration) * time.Minute)
+	if log.EndedAt != nil {
+		endedAt = *log.EndedAt
+	}
+
+	var overlappingID uint64
+	err := tx.Get(&overlappingID, `
+		SELECT id
+		FROM logs
+		WHERE
+			user_id = $1 AND
+			id <> $2 AND
+			deleted = FALSE AND
+			started_at IS NOT NULL AND
+			started_at < $4 AND
+			`+logSessionEndExpression+` > $3
+		ORDER BY started_at
+		LIMIT 1
+	`, log.UserID, log.ID, log.StartedAt.UTC(), endedAt.UTC())
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return &LogOverlapError{ID: log.ID, OverlappingID: overlappingID}
+}
+
+// isSameTime checks whether two optional times are the same
+func isSameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return a.Equal(*b)
+}
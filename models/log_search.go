@@ -0,0 +1,323 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// DefaultLogSearchLimit is the amount of results returned when no limit is given
+const DefaultLogSearchLimit = 50
+
+// MaxLogSearchLimit is the maximum amount of results returned at once
+const MaxLogSearchLimit = 100
+
+// logSearchBackfillBatchSize is the amount of logs indexed at once when backfilling
+const logSearchBackfillBatchSize = 500
+
+// Markers wrapped around the matches in highlights
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+)
+
+// LogSearchCollection is the result of a search through the notes of a user's logs
+type LogSearchCollection struct {
+	Results []LogSearchResult `json:"results"`
+}
+
+// LogSearchResult is a log matching a search, along with how well it matched
+type LogSearchResult struct {
+	Log
+	Rank float64 `json:"rank" db:"rank"`
+
+	// Highlights are the notes that matched with the matches wrapped in <mark>, the rest is HTML escaped
+	Highlights []string `json:"highlights"`
+}
+
+// Search the notes of a user's logs, best matches first
+func (logSearchCollection *LogSearchCollection) Search(userID uint64, query string, limit int) error {
+	db := GetDatabase()
+
+	logSearchCollection.Results = make([]LogSearchResult, 0)
+
+	tsQuery := searchQuery(query)
+	if tsQuery == "" {
+		validationErrors := ValidationErrors{}
+		validationErrors.Add("q", "invalid `Query` supplied, nothing to search for")
+		return validationErrors
+	}
+
+	if limit <= 0 {
+		limit = DefaultLogSearchLimit
+	} else if limit > MaxLogSearchLimit {
+		limit = MaxLogSearchLimit
+	}
+
+	err := db.Select(&logSearchCollection.Results, `
+		SELECT `+logColumns+`,
+			ts_rank(search_vector, to_tsquery('simple', $2)) AS rank
+		FROM logs
+		WHERE
+			user_id = $1 AND
+			deleted = FALSE AND
+			search_vector @@ to_tsquery('simple', $2)
+		ORDER BY rank DESC, date DESC, id DESC
+		LIMIT $3
+	`, userID, tsQuery, limit)
+	if err != nil {
+		return err
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	for index := range logSearchCollection.Results {
+		result := &logSearchCollection.Results[index]
+		result.Highlights = highlightNotes(result.Notes, terms)
+	}
+
+	return nil
+}
+
+// BackfillSearchVectors indexes the notes of the next batch of logs that were saved before notes could be searched,
+// returns how many logs were indexed so 0 means all logs are indexed
+func (logCollection *LogCollection) BackfillSearchVectors() (int64, error) {
+	db := GetDatabase()
+
+	logs := make([]Log, 0)
+	err := db.Select(&logs, `
+		SELECT
+			id,
+			notes
+		FROM logs
+		WHERE search_vector IS NULL
+		ORDER BY id
+		LIMIT $1
+	`, logSearchBackfillBatchSize)
+	if err != nil || len(logs) == 0 {
+		return 0, err
+	}
+
+	err = inTransaction(func(tx *sqlx.Tx) error {
+		for index := range logs {
+			err := setLogSearchVector(tx, &logs[index])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(logs)), nil
+}
+
+// setLogSearchVector indexes the notes of a log
+func setLogSearchVector(tx *sqlx.Tx, log *Log) error {
+	_, err := tx.Exec(`
+		UPDATE logs
+		SET search_vector = to_tsvector('simple', $2)
+		WHERE id = $1
+	`, log.ID, strings.Join(searchDocumentTokens(notesText(log.Notes)), " "))
+
+	return err
+}
+
+// notesText collects all text and numbers in notes
+func notesText(notes types.JSONText) []string {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(notes, &value); err != nil {
+		return nil
+	}
+
+	texts := make([]string, 0)
+	var collect func(value interface{})
+	collect = func(value interface{}) {
+		switch typedValue := value.(type) {
+		case string:
+			texts = append(texts, typedValue)
+		case float64:
+			texts = append(texts, fmt.Sprintf("%v", typedValue))
+		case []interface{}:
+			for _, item := range typedValue {
+				collect(item)
+			}
+		case map[string]interface{}:
+			// Keep the order stable
+			keys := make([]string, 0, len(typedValue))
+			for key := range typedValue {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				collect(typedValue[key])
+			}
+		}
+	}
+	collect(value)
+
+	return texts
+}
+
+// isCJK checks whether a character belongs to a script that doesn't separate words by spaces
+func isCJK(character rune) bool {
+	return unicode.In(character, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		character == 'ー' || character == '々'
+}
+
+// tokenize splits text into words, consecutive CJK characters are returned as a single run
+func tokenize(text string) (words []string, cjkRuns [][]rune) {
+	var word []rune
+	var cjkRun []rune
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+		if len(cjkRun) > 0 {
+			cjkRuns = append(cjkRuns, cjkRun)
+			cjkRun = nil
+		}
+	}
+
+	for _, character := range strings.ToLower(text) {
+		switch {
+		case isCJK(character):
+			if len(word) > 0 {
+				flush()
+			}
+			cjkRun = append(cjkRun, character)
+		case unicode.IsLetter(character) || unicode.IsDigit(character) || unicode.IsMark(character):
+			if len(cjkRun) > 0 {
+				flush()
+			}
+			word = append(word, character)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return words, cjkRuns
+}
+
+// searchDocumentTokens turns text into the tokens that are indexed.
+// PostgreSQL has no parser for languages without spaces, so CJK text is indexed
+// as single characters and bigrams which lets any part of a title be found.
+func searchDocumentTokens(texts []string) []string {
+	tokens := make([]string, 0)
+
+	for _, text := range texts {
+		words, cjkRuns := tokenize(text)
+		tokens = append(tokens, words...)
+
+		for _, cjkRun := range cjkRuns {
+			for index := range cjkRun {
+				tokens = append(tokens, string(cjkRun[index]))
+				if index+1 < len(cjkRun) {
+					tokens = append(tokens, string(cjkRun[index:index+2]))
+				}
+			}
+		}
+	}
+
+	return tokens
+}
+
+// searchQuery turns what a user searched for into a tsquery where every token has to match.
+// Tokens only contain letters and digits so they never need escaping.
+func searchQuery(query string) string {
+	tokens := make([]string, 0)
+
+	words, cjkRuns := tokenize(query)
+	tokens = append(tokens, words...)
+
+	for _, cjkRun := range cjkRuns {
+		if len(cjkRun) == 1 {
+			tokens = append(tokens, string(cjkRun))
+			continue
+		}
+		for index := 0; index+1 < len(cjkRun); index++ {
+			tokens = append(tokens, string(cjkRun[index:index+2]))
+		}
+	}
+
+	// Match words that are still being typed
+	for index, token := range tokens {
+		tokens[index] = token + ":*"
+	}
+
+	return strings.Join(tokens, " & ")
+}
+
+// highlightNotes returns the texts in notes that contain one of the terms with the matches marked
+func highlightNotes(notes types.JSONText, terms []string) []string {
+	highlights := make([]string, 0)
+
+	for _, text := range notesText(notes) {
+		if highlight, ok := highlight(text, terms); ok {
+			highlights = append(highlights, highlight)
+		}
+	}
+
+	return highlights
+}
+
+// highlight marks all case-insensitive occurrences of the terms in a text
+func highlight(text string, terms []string) (string, bool) {
+	characters := []rune(text)
+	lowered := []rune(strings.ToLower(text))
+	if len(lowered) != len(characters) {
+		lowered = characters
+	}
+
+	marked := make([]bool, len(characters))
+	found := false
+	for _, term := range terms {
+		termCharacters := []rune(term)
+		if len(termCharacters) == 0 {
+			continue
+		}
+
+		for start := 0; start+len(termCharacters) <= len(lowered); start++ {
+			if string(lowered[start:start+len(termCharacters)]) != term {
+				continue
+			}
+
+			for index := start; index < start+len(termCharacters); index++ {
+				marked[index] = true
+			}
+			found = true
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	var builder strings.Builder
+	for index, character := range characters {
+		if marked[index] && (index == 0 || !marked[index-1]) {
+			builder.WriteString(highlightStart)
+		}
+		builder.WriteString(html.EscapeString(string(character)))
+		if marked[index] && (index == len(characters)-1 || !marked[index+1]) {
+			builder.WriteString(highlightEnd)
+		}
+	}
+
+	return builder.String(), true
+}
@@ -0,0 +1,458 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+)
+
+// LogTemplateCollection array of log templates
+type LogTemplateCollection struct {
+	Templates []LogTemplate `json:"templates"`
+}
+
+// LogTemplate model, the fields of a log a user makes often such as `Daily Anki` so it can be logged in one go
+type LogTemplate struct {
+	ID               uint64         `json:"id" db:"id"`
+	UserID           uint64         `json:"user_id" db:"user_id"`
+	Name             string         `json:"name" db:"name"`
+	Language         enums.Language `json:"language" db:"language"`
+	Activity         enums.Activity `json:"activity" db:"activity"`
+	CustomActivityID *uint64        `json:"custom_activity_id" db:"custom_activity_id"`
+	Duration         uint64         `json:"duration" db:"duration"`
+	Notes            types.JSONText `json:"notes" db:"notes"`
+	ResourceID       *uint64        `json:"resource_id" db:"resource_id"`
+	Tags             pq.StringArray `json:"tags" db:"tags"`
+
+	// Weekdays the template is planned on, 1 is Monday and 7 is Sunday. Templates without weekdays aren't recurring.
+	Weekdays pq.Int64Array `json:"weekdays" db:"weekdays"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// LogTemplateLog are the fields that can be changed when a log is made from a template, all of them are optional
+type LogTemplateLog struct {
+	// Date defaults to today in the timezone of the user, it's always the day the session started when StartedAt is set
+	Date      string         `json:"date"`
+	StartedAt *time.Time     `json:"started_at"`
+	EndedAt   *time.Time     `json:"ended_at"`
+	Duration  uint64         `json:"duration"`
+	Notes     types.JSONText `json:"notes"`
+}
+
+// PlannedSession is a recurring template planned on a day
+type PlannedSession struct {
+	LogTemplate
+
+	// LogID is the log made from the template on the day, not set when it wasn't logged yet
+	LogID *uint64 `json:"log_id" db:"log_id"`
+}
+
+// PlannedSessionCollection are the sessions planned on a day
+type PlannedSessionCollection struct {
+	Date     string           `json:"date"`
+	Sessions []PlannedSession `json:"sessions"`
+}
+
+// maxLogTemplateNameLength is the longest name a log template can have
+const maxLogTemplateNameLength = 50
+
+// logTemplateColumns are the columns selected for a LogTemplate
+const logTemplateColumns = `
+			id,
+			user_id,
+			name,
+			language,
+			activity,
+			custom_activity_id,
+			duration,
+			notes,
+			resource_id,
+			tags,
+			weekdays,
+			created_at,
+			updated_at`
+
+// Length returns the amount of log templates in the collection
+func (logTemplateCollection *LogTemplateCollection) Length() int {
+	return len(logTemplateCollection.Templates)
+}
+
+// Validate the LogTemplate model
+func (logTemplate *LogTemplate) Validate() error {
+	validationErrors := ValidationErrors{}
+
+	if logTemplate.UserID == 0 {
+		validationErrors.Add("user_id", "invalid `UserID` supplied")
+	}
+	if strings.TrimSpace(logTemplate.Name) == "" {
+		validationErrors.Add("name", "invalid `Name` supplied")
+	} else if len([]rune(logTemplate.Name)) > maxLogTemplateNameLength {
+		validationErrors.Add("name", fmt.Sprintf("invalid `Name` supplied, can be at most %d characters", maxLogTemplateNameLength))
+	}
+	if len(logTemplate.Language) == 0 || !isSupportedLanguage(logTemplate.Language) {
+		validationErrors.Add("language", "invalid `Language` supplied")
+	}
+	// The activity of templates with a custom activity is looked up when they're saved
+	if (len(logTemplate.Activity) == 0 && logTemplate.CustomActivityID == nil) || (len(logTemplate.Activity) != 0 && !logTemplate.Activity.IsValid()) {
+		validationErrors.Add("activity", "invalid `Activity` supplied")
+	}
+	if logTemplate.CustomActivityID != nil && *logTemplate.CustomActivityID == 0 {
+		validationErrors.Add("custom_activity_id", "invalid `CustomActivityID` supplied")
+	}
+	if logTemplate.Duration == 0 {
+		validationErrors.Add("duration", "invalid `Duration` supplied")
+	} else if logTemplate.Duration > MaxLogDuration {
+		validationErrors.Add("duration", fmt.Sprintf("invalid `Duration` supplied, can be at most %d minutes", MaxLogDuration))
+	}
+	validateNotes(logTemplate.Activity, logTemplate.Notes, &validationErrors)
+	if logTemplate.ResourceID != nil && *logTemplate.ResourceID == 0 {
+		validationErrors.Add("resource_id", "invalid `ResourceID` supplied")
+	}
+	validateLogTags(logTemplate.Tags, &validationErrors)
+
+	seen := make(map[int64]bool)
+	for _, weekday := range logTemplate.Weekdays {
+		if weekday < 1 || weekday > 7 {
+			validationErrors.Add("weekdays", fmt.Sprintf("invalid `Weekdays` supplied, %d isn't between 1 (Monday) and 7 (Sunday)", weekday))
+		} else if seen[weekday] {
+			validationErrors.Add("weekdays", fmt.Sprintf("invalid `Weekdays` supplied, %d is used more than once", weekday))
+		}
+		seen[weekday] = true
+	}
+
+	return validationErrors.Err()
+}
+
+// IsOwner checks the owner
+func (logTemplate *LogTemplate) IsOwner(userID uint64) bool {
+	return logTemplate.UserID == userID
+}
+
+// GetAllFromUser returns all log templates from a certain user
+func (logTemplateCollection *LogTemplateCollection) GetAllFromUser(userID uint64) error {
+	db := GetDatabase()
+
+	err := db.Select(&logTemplateCollection.Templates, `
+		SELECT `+logTemplateColumns+`
+		FROM log_templates
+		WHERE user_id = $1
+		ORDER BY lower(name), id
+	`, userID)
+
+	return err
+}
+
+// Get a log template by id
+func (logTemplateCollection *LogTemplateCollection) Get(id uint64) (*LogTemplate, error) {
+	db := GetDatabase()
+
+	logTemplate := LogTemplate{}
+	err := db.Get(&logTemplate, `
+		SELECT `+logTemplateColumns+`
+		FROM log_templates
+		WHERE id = $1
+	`, id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "template", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &logTemplate, nil
+}
+
+// Add a log template to the database
+func (logTemplateCollection *LogTemplateCollection) Add(logTemplate *LogTemplate) (uint64, error) {
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		err := checkLogTemplate(tx, logTemplate)
+		if err != nil {
+			return err
+		}
+
+		stmt, err := tx.PrepareNamed(`
+			INSERT INTO log_templates (user_id, name, language, activity, custom_activity_id, duration, notes, resource_id, tags, weekdays)
+			VALUES (:user_id, :name, :language, :activity, :custom_activity_id, :duration, :notes, :resource_id, :tags, :weekdays)
+			RETURNING id
+		`)
+		if err != nil {
+			return err
+		}
+
+		return stmt.Get(&logTemplate.ID, logTemplate)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return logTemplate.ID, nil
+}
+
+// Update a log template, logs made from it before are kept as they are
+func (logTemplateCollection *LogTemplateCollection) Update(logTemplate *LogTemplate) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		err := checkLogTemplate(tx, logTemplate)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.NamedExec(`
+			UPDATE log_templates
+			SET
+				name = :name,
+				language = :language,
+				activity = :activity,
+				custom_activity_id = :custom_activity_id,
+				duration = :duration,
+				notes = :notes,
+				resource_id = :resource_id,
+				tags = :tags,
+				weekdays = :weekdays,
+				updated_at = (current_timestamp AT TIME ZONE 'UTC')
+			WHERE
+				id = :id AND
+				user_id = :user_id
+		`, logTemplate)
+		if err != nil {
+			return err
+		}
+
+		updated, err := result.RowsAffected()
+		if err == nil && updated == 0 {
+			return &NotFoundError{Resource: "template", ID: logTemplate.ID}
+		}
+
+		return err
+	})
+}
+
+// replaceTemplateTag renames a tag in the templates of a user, the tag is removed from them when newName isn't set.
+// Templates keep the names of their tags so they have to follow along when a tag changes.
+func replaceTemplateTag(tx *sqlx.Tx, userID uint64, name string, newName *string) error {
+	_, err := tx.Exec(`
+		UPDATE log_templates
+		SET
+			tags = ARRAY(
+				SELECT CASE WHEN lower(template_tags.tag) = lower($2) THEN CAST($3 AS text) ELSE template_tags.tag END
+				FROM unnest(tags) WITH ORDINALITY AS template_tags (tag, position)
+				WHERE
+					lower(template_tags.tag) <> lower($2) OR
+					CAST($3 AS text) IS NOT NULL
+				ORDER BY template_tags.position
+			),
+			updated_at = (current_timestamp AT TIME ZONE 'UTC')
+		WHERE
+			user_id = $1 AND
+			lower($2) IN (SELECT lower(tag) FROM unnest(tags) AS tag)
+	`, userID, name, newName)
+
+	return err
+}
+
+// Delete a log template, logs made from it are kept. changedBy is the user deleting it
+func (logTemplateCollection *LogTemplateCollection) Delete(logTemplate *LogTemplate, changedBy uint64) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		err := unlinkLogs(tx, "template_id", logTemplate.ID, logTemplate.UserID, changedBy)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(`
+			DELETE FROM log_templates
+			WHERE
+				id = $1 AND
+				user_id = $2
+		`, logTemplate.ID, logTemplate.UserID)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err == nil && deleted == 0 {
+			return &NotFoundError{Resource: "template", ID: logTemplate.ID}
+		}
+
+		return err
+	})
+}
+
+// AddLog makes a log from a template, changedBy is the user making it. Returns the log as it was stored
+func (logTemplateCollection *LogTemplateCollection) AddLog(logTemplate *LogTemplate, templateLog *LogTemplateLog, changedBy uint64) (*Log, error) {
+	var newLog *Log
+
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		log := logTemplate.newLog(templateLog)
+		if log.Date == "" && log.StartedAt == nil {
+			date, err := getUserToday(tx, log.UserID)
+			if err != nil {
+				return err
+			}
+			log.Date = date
+		}
+
+		err := log.Validate()
+		if err != nil {
+			return err
+		}
+
+		newLog, err = addLog(tx, log, changedBy)
+		return err
+	})
+
+	return newLog, err
+}
+
+// newLog builds a log from the template with the changes made to it
+func (logTemplate *LogTemplate) newLog(templateLog *LogTemplateLog) *Log {
+	log := &Log{
+		UserID:           logTemplate.UserID,
+		Language:         logTemplate.Language,
+		Date:             templateLog.Date,
+		Duration:         logTemplate.Duration,
+		Activity:         logTemplate.Activity,
+		Notes:            logTemplate.Notes,
+		StartedAt:        templateLog.StartedAt,
+		EndedAt:          templateLog.EndedAt,
+		ResourceID:       logTemplate.ResourceID,
+		CustomActivityID: logTemplate.CustomActivityID,
+		TemplateID:       &logTemplate.ID,
+		Tags:             logTemplate.Tags,
+	}
+	// The category of the custom activity might have changed since the template was saved
+	if logTemplate.CustomActivityID != nil {
+		log.Activity = ""
+	}
+	if templateLog.Duration != 0 {
+		log.Duration = templateLog.Duration
+	} else if templateLog.EndedAt != nil {
+		// Derive the duration from the session instead
+		log.Duration = 0
+	}
+	if len(templateLog.Notes) > 0 {
+		log.Notes = templateLog.Notes
+	}
+
+	return log
+}
+
+// GetFromUser returns the recurring templates of a user planned on a date, today in the timezone of the user when it's empty
+func (plannedSessionCollection *PlannedSessionCollection) GetFromUser(userID uint64, date string) error {
+	db := GetDatabase()
+
+	if date == "" {
+		today, err := getUserToday(db, userID)
+		if err != nil {
+			return err
+		}
+		date = today
+	}
+	if _, err := time.Parse(DateFormat, date); err != nil {
+		validationErrors := ValidationErrors{}
+		validationErrors.Add("date", "invalid `Date` supplied, expected format YYYY-MM-DD")
+		return validationErrors
+	}
+	plannedSessionCollection.Date = date
+
+	err := db.Select(&plannedSessionCollection.Sessions, `
+		SELECT `+logTemplateColumns+`,
+			(
+				SELECT logs.id
+				FROM logs
+				WHERE
+					logs.template_id = log_templates.id AND
+					logs.date = CAST($2 AS date) AND
+					logs.deleted = FALSE
+				ORDER BY logs.id
+				LIMIT 1
+			) AS log_id
+		FROM log_templates
+		WHERE
+			user_id = $1 AND
+			CAST(EXTRACT(isodow FROM CAST($2 AS date)) AS smallint) = ANY(weekdays)
+		ORDER BY lower(name), id
+	`, userID, date)
+
+	return err
+}
+
+// checkLogTemplate makes sure the name of a template is unique for its user and that it only uses
+// resources and custom activities of its user, templates with a custom activity get the activity of its category
+func checkLogTemplate(tx *sqlx.Tx, logTemplate *LogTemplate) error {
+	var exists bool
+	err := tx.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM log_templates
+			WHERE
+				user_id = $1 AND
+				lower(name) = lower($2) AND
+				id <> $3
+		)
+	`, logTemplate.UserID, strings.TrimSpace(logTemplate.Name), logTemplate.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		validationErrors := ValidationErrors{}
+		validationErrors.Add("name", "invalid `Name` supplied, another template already has this name")
+		return validationErrors
+	}
+
+	log := &Log{UserID: logTemplate.UserID, Activity: logTemplate.Activity, Notes: logTemplate.Notes, ResourceID: logTemplate.ResourceID, CustomActivityID: logTemplate.CustomActivityID}
+	err = checkResourceOwner(tx, log)
+	if err != nil {
+		return err
+	}
+	err = checkCustomActivity(tx, log)
+	if err != nil {
+		return err
+	}
+
+	logTemplate.Name = strings.TrimSpace(logTemplate.Name)
+	logTemplate.Activity = log.Activity
+	if logTemplate.Tags == nil {
+		logTemplate.Tags = pq.StringArray{}
+	}
+	if logTemplate.Weekdays == nil {
+		logTemplate.Weekdays = pq.Int64Array{}
+	}
+
+	return nil
+}
+
+// checkLogTemplateOwner makes sure a log is only made from templates of its user
+func checkLogTemplateOwner(tx *sqlx.Tx, log *Log) error {
+	if log.TemplateID == nil {
+		return nil
+	}
+
+	var exists bool
+	err := tx.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM log_templates
+			WHERE
+				id = $1 AND
+				user_id = $2
+		)
+	`, *log.TemplateID, log.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		validationErrors := ValidationErrors{}
+		validationErrors.Add("template_id", "invalid `TemplateID` supplied")
+		return validationErrors
+	}
+
+	return nil
+}
@@ -1,12 +1,16 @@
 package models
 
 import (
+	"database/sql"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"strconv"
+	"time"
 
 	"github.com/antonve/logger-api/models/enums"
+	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 )
 
 // LogCollection array of logs
@@ -23,29 +27,158 @@ type Log struct {
 	Duration uint64         `json:"duration" db:"duration"`
 	Activity enums.Activity `json:"activity" db:"activity"`
 	Notes    types.JSONText `json:"notes" db:"notes"`
+
+	// PagesRead and CharactersRead are the amount read in the session, they're taken from the notes when the notes track them
+	PagesRead      *uint64 `json:"pages_read" db:"pages_read"`
+	CharactersRead *uint64 `json:"characters_read" db:"characters_read"`
+
+	// StartedAt and EndedAt are when the session took place, both are optional.
+	// Date is derived from StartedAt in the timezone of the user when it's set,
+	// Duration is derived from the time between them when it's not set.
+	StartedAt *time.Time `json:"started_at" db:"started_at"`
+	EndedAt   *time.Time `json:"ended_at" db:"ended_at"`
+
+	// ResourceID links the log to the book, show, podcast or deck that was studied
+	ResourceID *uint64 `json:"resource_id" db:"resource_id"`
+
+	// CustomActivityID is the activity the user defined themselves, Activity is set to its category
+	CustomActivityID *uint64 `json:"custom_activity_id" db:"custom_activity_id"`
+
+	// TemplateID is the template the log was made from, it's only set when a log is created
+	TemplateID *uint64 `json:"template_id" db:"template_id"`
+
+	// Tags are the names of the tags of the log, they're kept as they are when a log is updated without them
+	Tags pq.StringArray `json:"tags" db:"tags"`
+
+	// Version is bumped on every change, clients send it back to make sure they don't overwrite newer changes
+	Version   uint64    `json:"version" db:"version"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Only set for logs in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// LogPage is a page of logs, newest first
+type LogPage struct {
+	LogCollection
+
+	// NextCursor is passed as `cursor` to get the next page, not set on the last page
+	NextCursor *string `json:"next_cursor"`
+	TotalLogs  uint64  `json:"total_logs" db:"total_logs"`
+	TotalDates uint64  `json:"total_dates" db:"total_dates"`
 }
 
+// DefaultLogPageSize is the amount of dates on a page of logs when no limit is given
+const DefaultLogPageSize = 30
+
+// MaxLogPageSize is the maximum amount of dates on a page of logs
+const MaxLogPageSize = 100
+
+// ErrInvalidCursor is returned when a cursor wasn't handed out by us
+var ErrInvalidCursor = errors.New("invalid cursor supplied")
+
 // Length returns the amount of logs in the collection
 func (logCollection *LogCollection) Length() int {
 	return len(logCollection.Logs)
 }
 
+// DateFormat is the format in which log dates are sent and received
+const DateFormat = "2006-01-02"
+
+// MaxLogDuration is the longest duration in minutes a single log can have
+const MaxLogDuration = 24 * 60
+
+// logColumns are the columns selected for a Log
+const logColumns = `
+			id,
+			user_id,
+			language,
+			to_char(date, 'YYYY-MM-DD') AS date,
+			duration,
+			activity,
+			notes,
+			pages_read,
+			characters_read,
+			started_at,
+			ended_at,
+			resource_id,
+			custom_activity_id,
+			template_id,
+			version,
+			created_at,
+			updated_at,` + logTagsColumn
+
+// logChangeColumns are the columns set on every change to a log so it shows up in the change feed
+const logChangeColumns = `
+			version = version + 1,
+			updated_at = (current_timestamp AT TIME ZONE 'UTC'),
+			change_seq = NEXTVAL('logs_change_seq')`
+
 // Validate the Log model
 func (log *Log) Validate() error {
+	validationErrors := ValidationErrors{}
+
 	if log.UserID == 0 {
-		return errors.New("invalid `UserID` supplied")
+		validationErrors.Add("user_id", "invalid `UserID` supplied")
 	}
-	if log.Date == "" {
-		return errors.New("invalid `Date` supplied")
+	// The date of logs with a start time is derived when they're saved
+	if log.Date == "" && log.StartedAt == nil {
+		validationErrors.Add("date", "invalid `Date` supplied")
+	} else if _, err := time.Parse(DateFormat, log.Date); log.Date != "" && err != nil {
+		validationErrors.Add("date", "invalid `Date` supplied, expected format YYYY-MM-DD")
 	}
-	if log.Duration == 0 {
-		return errors.New("invalid `Duration` supplied")
+	if log.EndedAt != nil {
+		if log.StartedAt == nil {
+			validationErrors.Add("ended_at", "invalid `EndedAt` supplied, `StartedAt` is required as well")
+		} else if !log.EndedAt.After(*log.StartedAt) {
+			validationErrors.Add("ended_at", "invalid `EndedAt` supplied, must be after `StartedAt`")
+		} else if log.span() > MaxLogDuration {
+			validationErrors.Add("ended_at", fmt.Sprintf("invalid `EndedAt` supplied, can be at most %d minutes after `StartedAt`", MaxLogDuration))
+		} else if log.Duration > log.span() {
+			validationErrors.Add("duration", "invalid `Duration` supplied, can't be longer than the time between `StartedAt` and `EndedAt`")
+		}
+	}
+	if log.Duration == 0 && (log.EndedAt == nil || log.span() == 0) {
+		validationErrors.Add("duration", "invalid `Duration` supplied")
+	} else if log.Duration > MaxLogDuration {
+		validationErrors.Add("duration", fmt.Sprintf("invalid `Duration` supplied, can be at most %d minutes", MaxLogDuration))
+	}
+	if len(log.Language) == 0 || !isSupportedLanguage(log.Language) {
+		validationErrors.Add("language", "invalid `Language` supplied")
+	}
+	// The activity of logs with a custom activity is looked up when they're saved
+	if (len(log.Activity) == 0 && log.CustomActivityID == nil) || (len(log.Activity) != 0 && !log.Activity.IsValid()) {
+		validationErrors.Add("activity", "invalid `Activity` supplied")
+	}
+	validateNotes(log.Activity, log.Notes, &validationErrors)
+	if log.PagesRead != nil && *log.PagesRead == 0 {
+		validationErrors.Add("pages_read", "invalid `PagesRead` supplied")
+	}
+	if log.CharactersRead != nil && *log.CharactersRead == 0 {
+		validationErrors.Add("characters_read", "invalid `CharactersRead` supplied")
+	}
+	if log.ResourceID != nil && *log.ResourceID == 0 {
+		validationErrors.Add("resource_id", "invalid `ResourceID` supplied")
+	}
+	if log.CustomActivityID != nil && *log.CustomActivityID == 0 {
+		validationErrors.Add("custom_activity_id", "invalid `CustomActivityID` supplied")
 	}
-	if len(log.Activity) == 0 || !log.Activity.IsValid() {
-		return errors.New("invalid `Activity` supplied")
+	if log.TemplateID != nil && *log.TemplateID == 0 {
+		validationErrors.Add("template_id", "invalid `TemplateID` supplied")
 	}
+	validateLogTags(log.Tags, &validationErrors)
 
-	return nil
+	return validationErrors.Err()
+}
+
+// span is the time between StartedAt and EndedAt rounded to minutes
+func (log *Log) span() uint64 {
+	if log.StartedAt == nil || log.EndedAt == nil || !log.EndedAt.After(*log.StartedAt) {
+		return 0
+	}
+
+	return uint64((log.EndedAt.Sub(*log.StartedAt) + 30*time.Second) / time.Minute)
 }
 
 // ByLanguage only keeps the logs for a certain language
@@ -64,17 +197,9 @@ func (logCollection *LogCollection) ByLanguage(language enums.Language) {
 // GetAll returns all logs
 func (logCollection *LogCollection) GetAll() error {
 	db := GetDatabase()
-	defer db.Close()
 
 	err := db.Select(&logCollection.Logs, `
-		SELECT
-			id,
-			user_id,
-			language,
-			to_char(date, 'YYYY-MM-DD') AS date,
-			duration,
-			activity,
-			notes
+		SELECT `+logColumns+`
 		FROM logs
 		WHERE deleted = FALSE
 	`)
@@ -85,17 +210,9 @@ func (logCollection *LogCollection) GetAll() error {
 // GetAllFromUser returns all logs from a certain user
 func (logCollection *LogCollection) GetAllFromUser(userID uint64) error {
 	db := GetDatabase()
-	defer db.Close()
 
 	err := db.Select(&logCollection.Logs, `
-		SELECT
-			id,
-			user_id,
-			language,
-			to_char(date, 'YYYY-MM-DD') AS date,
-			duration,
-			activity,
-			notes
+		SELECT `+logColumns+`
 		FROM logs
 		WHERE
 			user_id = $1 AND
@@ -105,121 +222,138 @@ func (logCollection *LogCollection) GetAllFromUser(userID uint64) error {
 	return err
 }
 
-// GetAllWithFilters returns all logs with filters applied
-func (logCollection *LogCollection) GetAllWithFilters(filters map[string]interface{}) error {
+// GetAllWithFilters returns a page of logs with filters applied, pages contain all logs of a number of dates.
+// Pages are selected by the date the previous page ended on so they don't shift when logs are added in the meantime.
+func (logPage *LogPage) GetAllWithFilters(filters *LogFilters, cursor string, limit int) error {
 	db := GetDatabase()
-	defer db.Close()
-
-	where := "DELETED = FALSE"
-
-	for filter, value := range filters {
-		switch filter {
-		case "user_id":
-			filters["user_id"] = value.(uint64)
-			if filters["user_id"] != 0 {
-				where = where + " AND user_id = :user_id"
-			}
-		case "date":
-			if value != "" {
-				where = where + " AND date = :date"
-			}
-		case "from":
-			if value != "" {
-				where = where + " AND date >= :from"
-			}
-		case "until":
-			if value != "" {
-				where = where + " AND date <= :until"
-			}
-		case "language":
-			if value != "" {
-				where = where + " AND language = :language"
-			}
-		case "page":
-			page, err := strconv.ParseUint(value.(string), 10, 64)
-			if err != nil || page <= 0 {
-				page = 1
-			}
-
-			filters["page"] = (page - 1) * 30
+
+	err := filters.Validate()
+	if err != nil {
+		return err
+	}
+
+	where, args := filters.where()
+
+	// Totals ignore the cursor so they stay the same for every page
+	rows, err := db.NamedQuery(`
+		SELECT
+			COUNT(*) AS total_logs,
+			COUNT(DISTINCT date) AS total_dates
+		FROM logs
+		WHERE `+where, args)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		err = rows.StructScan(logPage)
+	}
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	dateDirection := "ASC"
+	if filters.isDateDescending() {
+		dateDirection = "DESC"
+	}
+
+	pageWhere := where
+	if cursor != "" {
+		after, ok := parseLogPageCursor(cursor)
+		if !ok {
+			return ErrInvalidCursor
+		}
+
+		args["after"] = after
+		if filters.isDateDescending() {
+			pageWhere = pageWhere + " AND date < :after"
+		} else {
+			pageWhere = pageWhere + " AND date > :after"
 		}
 	}
 
-	if _, ok := filters["page"]; !ok {
-		filters["page"] = 0
+	if limit <= 0 {
+		limit = DefaultLogPageSize
+	} else if limit > MaxLogPageSize {
+		limit = MaxLogPageSize
 	}
 
+	// Fetch one extra date to know whether there's another page
+	args["limit"] = limit + 1
+
 	query := `
-		WITH filtered_logs AS (
-			SELECT
-				unnest(ids) AS id
-			FROM (
-				SELECT
-					array_agg(id) AS ids
-				FROM logs
-				WHERE ` + where + `
-				GROUP BY date
-				ORDER BY date DESC
-				OFFSET :page
-				LIMIT 30
-			) AS agg_ids
+		WITH page_dates AS (
+			SELECT date
+			FROM logs
+			WHERE ` + pageWhere + `
+			GROUP BY date
+			ORDER BY date ` + dateDirection + `
+			LIMIT :limit
 		)
-		SELECT
-			id,
-			user_id,
-			language,
-			to_char(date, 'YYYY-MM-DD') AS date,
-			duration,
-			activity,
-			notes
-		FROM logs l
-		WHERE EXISTS (
-			SELECT 1
-			FROM filtered_logs fl
-			WHERE fl.id = l.id
-		)
-		ORDER BY date DESC, language
+		SELECT ` + logColumns + `
+		FROM logs
+		WHERE ` + where + ` AND date IN (SELECT date FROM page_dates)
+		ORDER BY ` + filters.orderBy() + `
 	`
 
-	rows, err := db.NamedQuery(query, filters)
-	defer rows.Close()
-
+	rows, err = db.NamedQuery(query, args)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
+	logPage.Logs = make([]Log, 0)
+	dates := 0
 	for rows.Next() {
 		var log Log
 		err = rows.StructScan(&log)
-
 		if err != nil {
 			return err
 		}
 
-		logCollection.Logs = append(logCollection.Logs, log)
+		if len(logPage.Logs) == 0 || logPage.Logs[len(logPage.Logs)-1].Date != log.Date {
+			dates++
+		}
+		if dates > limit {
+			nextCursor := encodeLogPageCursor(logPage.Logs[len(logPage.Logs)-1].Date)
+			logPage.NextCursor = &nextCursor
+			break
+		}
+
+		logPage.Logs = append(logPage.Logs, log)
 	}
 
-	return err
+	return rows.Err()
+}
+
+// encodeLogPageCursor turns the last date of a page into an opaque cursor
+func encodeLogPageCursor(date string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(date))
+}
+
+// parseLogPageCursor gets the date a page ended on from a cursor
+func parseLogPageCursor(cursor string) (string, bool) {
+	date, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", false
+	}
+	if _, err := time.Parse(DateFormat, string(date)); err != nil {
+		return "", false
+	}
+
+	return string(date), true
 }
 
 // Get a log by id
 func (logCollection *LogCollection) Get(id uint64) (*Log, error) {
 	db := GetDatabase()
-	defer db.Close()
 
 	// Init log
 	log := Log{}
 
 	// Get log
 	stmt, err := db.Preparex(`
-		SELECT
-			id,
-			user_id,
-			language,
-			to_char(date, 'YYYY-MM-DD') AS date,
-			duration,
-			activity,
-			notes
+		SELECT ` + logColumns + `
 		FROM logs
 		WHERE
 			id = $1 AND
@@ -231,98 +365,448 @@ func (logCollection *LogCollection) Get(id uint64) (*Log, error) {
 
 	stmt.Get(&log, id)
 	if log.ID == 0 {
-		return nil, fmt.Errorf("no log found with id %v", id)
+		return nil, &NotFoundError{Resource: "log", ID: id}
 	}
 
 	return &log, nil
 }
 
-// Add a log to the database
-func (logCollection *LogCollection) Add(log *Log) (uint64, error) {
+// Add a log to the database, changedBy is the user adding it
+func (logCollection *LogCollection) Add(log *Log, changedBy uint64) (uint64, error) {
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		_, err := addLog(tx, log, changedBy)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return log.ID, nil
+}
+
+// Update a log, changedBy is the user updating it
+func (logCollection *LogCollection) Update(log *Log, changedBy uint64) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		_, err := updateLog(tx, log, changedBy)
+		return err
+	})
+}
+
+// Delete a log, it's kept in the trash until it's purged. changedBy is the user deleting it
+func (logCollection *LogCollection) Delete(log *Log, changedBy uint64) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		return deleteLog(tx, log, changedBy)
+	})
+}
+
+// GetTrashFromUser returns all deleted logs from a certain user that haven't been purged yet
+func (logCollection *LogCollection) GetTrashFromUser(userID uint64) error {
 	db := GetDatabase()
-	defer db.Close()
 
-	query := `
-		INSERT INTO logs (user_id, language, date, duration, activity, notes)
-		VALUES (:user_id, :language, :date, :duration, :activity, :notes)
-		RETURNING id
-	`
-	rows, err := db.NamedQuery(query, log)
-	defer rows.Close()
+	err := db.Select(&logCollection.Logs, `
+		SELECT `+logColumns+`,
+			deleted_at
+		FROM logs
+		WHERE
+			user_id = $1 AND
+			deleted = TRUE
+		ORDER BY deleted_at DESC, id DESC
+	`, userID)
 
+	return err
+}
+
+// Restore a deleted log of a user, changedBy is the user restoring it
+func (logCollection *LogCollection) Restore(log *Log, changedBy uint64) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		return restoreLog(tx, log, changedBy)
+	})
+}
+
+// PurgeDeleted permanently removes logs that have been in the trash longer than the retention period.
+// A tombstone is kept for every purged log so clients that sync afterwards still learn it's gone.
+func (logCollection *LogCollection) PurgeDeleted(retention time.Duration) (int64, error) {
+	db := GetDatabase()
+
+	// The tombstone keeps the change_seq of the deletion, it was already committed in order
+	result, err := db.Exec(`
+		WITH purged AS (
+			DELETE FROM logs
+			WHERE
+				deleted = TRUE AND
+				deleted_at < (current_timestamp AT TIME ZONE 'UTC') - $1 * INTERVAL '1 second'
+			RETURNING id, user_id, change_seq
+		)
+		INSERT INTO log_tombstones (log_id, user_id, change_seq)
+		SELECT id, user_id, change_seq
+		FROM purged
+	`, retention.Seconds())
 	if err != nil {
 		return 0, err
 	}
 
-	var id uint64
-	if rows.Next() {
-		rows.Scan(&id)
+	return result.RowsAffected()
+}
+
+// IsOwner checks the owner
+func (log *Log) IsOwner(userID uint64) bool {
+	if log.UserID == userID {
+		return true
 	}
 
-	return id, nil
+	return false
 }
 
-// Update a log
-func (logCollection *LogCollection) Update(log *Log) error {
-	db := GetDatabase()
-	defer db.Close()
+// addLog inserts a log and records its creation by changedBy, returns the log as it was stored
+func addLog(tx *sqlx.Tx, log *Log, changedBy uint64) (*Log, error) {
+	err := lockLogChanges(tx, log.UserID)
+	if err != nil {
+		return nil, err
+	}
 
-	query := `
+	err = checkResourceOwner(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkCustomActivity(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkLogTemplateOwner(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	setLogAmountRead(log)
+
+	err = setLogTimes(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkLogOverlap(tx, log, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareNamed(`
+		INSERT INTO logs (user_id, language, date, duration, activity, notes, pages_read, characters_read, started_at, ended_at, resource_id, custom_activity_id, template_id)
+		VALUES (:user_id, :language, :date, :duration, :activity, :notes, :pages_read, :characters_read, :started_at, :ended_at, :resource_id, :custom_activity_id, :template_id)
+		RETURNING id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stmt.Get(&log.ID, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setLogSearchVector(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setLogTags(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	newLog, err := getLogForUpdate(tx, log.ID, log.UserID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLog, addLogHistory(tx, changedBy, enums.LogActionCreate, nil, newLog)
+}
+
+// updateLog updates a log of a user and records the old and new values along with changedBy, returns the log as it was stored
+func updateLog(tx *sqlx.Tx, log *Log, changedBy uint64) (*Log, error) {
+	err := lockLogChanges(tx, log.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldLog, err := getLogForUpdate(tx, log.ID, log.UserID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only check the version when the client told us which version its changes are based on
+	if log.Version != 0 && log.Version != oldLog.Version {
+		return nil, &VersionConflictError{Resource: "log", ID: log.ID, Version: log.Version, CurrentVersion: oldLog.Version}
+	}
+
+	err = checkResourceOwner(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkCustomActivity(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	setLogAmountRead(log)
+
+	err = setLogTimes(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkLogOverlap(tx, log, oldLog)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.NamedExec(`
 		UPDATE logs
 		SET
 			language = :language,
 			date = :date,
 			duration = :duration,
 			activity = :activity,
-			notes = :notes
-		WHERE
-			id = :id AND
-			user_id = :user_id AND
-			deleted = FALSE
-	`
-	result, err := db.NamedExec(query, log)
+			notes = :notes,
+			pages_read = :pages_read,
+			characters_read = :characters_read,
+			started_at = :started_at,
+			ended_at = :ended_at,
+			resource_id = :resource_id,
+			custom_activity_id = :custom_activity_id,`+logChangeColumns+`
+		WHERE id = :id
+	`, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setLogSearchVector(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setLogTags(tx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	newLog, err := getLogForUpdate(tx, log.ID, log.UserID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLog, addLogHistory(tx, changedBy, enums.LogActionUpdate, oldLog, newLog)
+}
+
+// deleteLog moves a log of a user to the trash and records it was deleted by changedBy
+func deleteLog(tx *sqlx.Tx, log *Log, changedBy uint64) error {
+	err := lockLogChanges(tx, log.UserID)
 	if err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
-	if rows == 0 {
-		err = fmt.Errorf("no log found with id %d for user %d", log.ID, log.UserID)
+	oldLog, err := getLogForUpdate(tx, log.ID, log.UserID, false)
+	if err != nil {
+		return err
 	}
 
-	return err
+	// Only check the version when the client told us which version it deletes
+	if log.Version != 0 && log.Version != oldLog.Version {
+		return &VersionConflictError{Resource: "log", ID: log.ID, Version: log.Version, CurrentVersion: oldLog.Version}
+	}
+
+	_, err = tx.NamedExec(`
+		UPDATE logs
+		SET
+			deleted = TRUE,
+			deleted_at = (current_timestamp AT TIME ZONE 'UTC'),`+logChangeColumns+`
+		WHERE id = :id
+	`, log)
+	if err != nil {
+		return err
+	}
+
+	return addLogHistory(tx, changedBy, enums.LogActionDelete, oldLog, nil)
 }
 
-// Delete a log
-func (logCollection *LogCollection) Delete(log *Log) error {
-	db := GetDatabase()
-	defer db.Close()
+// restoreLog takes a log of a user out of the trash and records it was restored by changedBy
+func restoreLog(tx *sqlx.Tx, log *Log, changedBy uint64) error {
+	err := lockLogChanges(tx, log.UserID)
+	if err != nil {
+		return err
+	}
 
-	query := `
+	deletedLog, err := getLogForUpdate(tx, log.ID, log.UserID, true)
+	if err != nil {
+		return err
+	}
+
+	err = checkLogOverlap(tx, deletedLog, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExec(`
 		UPDATE logs
-		SET deleted = TRUE
+		SET
+			deleted = FALSE,
+			deleted_at = NULL,`+logChangeColumns+`
+		WHERE id = :id
+	`, log)
+	if err != nil {
+		return err
+	}
+
+	newLog, err := getLogForUpdate(tx, log.ID, log.UserID, false)
+	if err != nil {
+		return err
+	}
+
+	return addLogHistory(tx, changedBy, enums.LogActionRestore, nil, newLog)
+}
+
+// getLogForUpdate gets a log of a user and locks it until the transaction ends
+func getLogForUpdate(tx *sqlx.Tx, id uint64, userID uint64, deleted bool) (*Log, error) {
+	log := Log{}
+
+	err := tx.Get(&log, `
+		SELECT `+logColumns+`
+		FROM logs
 		WHERE
-			id = :id AND
-			deleted = FALSE
-	`
-	result, err := db.NamedExec(query, log)
+			id = $1 AND
+			user_id = $2 AND
+			deleted = $3
+		FOR UPDATE
+	`, id, userID, deleted)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "log", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &log, nil
+}
+
+// getLogsForUpdate gets the logs of a user matching a condition and locks them until the transaction ends,
+// the arguments of the condition start at $2
+func getLogsForUpdate(tx *sqlx.Tx, userID uint64, condition string, args ...interface{}) ([]Log, error) {
+	err := lockLogChanges(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]Log, 0)
+	err = tx.Select(&logs, `
+		SELECT `+logColumns+`
+		FROM logs
+		WHERE
+			user_id = $1 AND
+			`+condition+`
+		ORDER BY id
+		FOR UPDATE
+	`, append([]interface{}{userID}, args...)...)
+
+	return logs, err
+}
+
+// recordLogChanges makes logs that were changed along with another record show up in the change feed
+// and records their old and new values, oldLogs are the logs as they were before the change
+func recordLogChanges(tx *sqlx.Tx, oldLogs []Log, changedBy uint64) error {
+	if len(oldLogs) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, len(oldLogs))
+	for index, oldLog := range oldLogs {
+		ids[index] = int64(oldLog.ID)
+	}
+
+	_, err := tx.Exec(`
+		UPDATE logs
+		SET`+logChangeColumns+`
+		WHERE id = ANY($1)
+	`, ids)
 	if err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
-	if rows == 0 {
-		err = fmt.Errorf("no log found with id %v or it has already been deleted", log.ID)
+	newLogs := make([]Log, 0)
+	err = tx.Select(&newLogs, `
+		SELECT `+logColumns+`
+		FROM logs
+		WHERE id = ANY($1)
+		ORDER BY id
+	`, ids)
+	if err != nil {
+		return err
 	}
 
-	return err
+	for index := range newLogs {
+		err = addLogHistory(tx, changedBy, enums.LogActionUpdate, &oldLogs[index], &newLogs[index])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// IsOwner checks the owner
-func (log *Log) IsOwner(userID uint64) bool {
-	if log.UserID == userID {
-		return true
+// unlinkLogs removes the link from the logs of a user to a record that is deleted, column is the column that links them.
+// Unlink logs ourselves instead of relying on the foreign key so the change shows up in the change feed and the history of the logs.
+func unlinkLogs(tx *sqlx.Tx, column string, id uint64, userID uint64, changedBy uint64) error {
+	oldLogs, err := getLogsForUpdate(tx, userID, column+` = $2`, id)
+	if err != nil {
+		return err
 	}
 
-	return false
+	_, err = tx.Exec(`
+		UPDATE logs
+		SET `+column+` = NULL
+		WHERE
+			user_id = $1 AND
+			`+column+` = $2
+	`, userID, id)
+	if err != nil {
+		return err
+	}
+
+	return recordLogChanges(tx, oldLogs, changedBy)
+}
+
+// setLogAmountRead takes the amount read from the notes of a log, the notes win when they track it as well
+func setLogAmountRead(log *Log) {
+	pages, characters := notesAmountRead(log.Activity, log.Notes)
+	if pages > 0 {
+		log.PagesRead = &pages
+	}
+	if characters > 0 {
+		log.CharactersRead = &characters
+	}
+}
+
+// setLogTimes stores the start and end of a log in UTC, its duration is derived from them when it's not set.
+// Its date is always derived from the start in the timezone of the user so the log can't end up on another day.
+func setLogTimes(tx *sqlx.Tx, log *Log) error {
+	if log.StartedAt == nil {
+		return nil
+	}
+
+	startedAt := log.StartedAt.UTC()
+	log.StartedAt = &startedAt
+	if log.EndedAt != nil {
+		endedAt := log.EndedAt.UTC()
+		log.EndedAt = &endedAt
+	}
+	if log.Duration == 0 {
+		log.Duration = log.span()
+	}
+
+	return tx.Get(&log.Date, `
+		SELECT to_char(CAST($1 AS timestamp) AT TIME ZONE 'UTC' AT TIME ZONE COALESCE(NULLIF(preferences ->> 'timezone', ''), $3), 'YYYY-MM-DD')
+		FROM users
+		WHERE id = $2
+	`, startedAt, log.UserID, DefaultTimezone)
 }
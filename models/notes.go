@@ -0,0 +1,259 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// maxNotesTextLength is the longest a text field in notes can be
+const maxNotesTextLength = 255
+
+// maxNotesCommentLength is the longest a comment in notes can be
+const maxNotesCommentLength = 2000
+
+// Notes are the details of a log, every activity has its own fields
+type Notes interface {
+	validate(validationErrors *ValidationErrors)
+}
+
+// ReadingNotes are the notes of a READING log
+type ReadingNotes struct {
+	Type       enums.ReadingType `json:"type,omitempty"`
+	Series     string            `json:"series,omitempty"`
+	Volume     uint64            `json:"volume,omitempty"`
+	Pages      uint64            `json:"pages,omitempty"`
+	Characters uint64            `json:"characters,omitempty"`
+	Comment    string            `json:"comment,omitempty"`
+}
+
+// ListeningNotes are the notes of a LISTENING log
+type ListeningNotes struct {
+	Source  string `json:"source,omitempty"`
+	Episode uint64 `json:"episode,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// FlashcardsNotes are the notes of a FLASHCARDS log
+type FlashcardsNotes struct {
+	Deck     string `json:"deck,omitempty"`
+	Reviews  uint64 `json:"reviews,omitempty"`
+	NewCards uint64 `json:"new_cards,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// TextbookNotes are the notes of a TEXTBOOK log
+type TextbookNotes struct {
+	Book    string `json:"book,omitempty"`
+	Chapter string `json:"chapter,omitempty"`
+	Pages   uint64 `json:"pages,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// TranslationNotes are the notes of a TRANSLATION log
+type TranslationNotes struct {
+	Source     string `json:"source,omitempty"`
+	Characters uint64 `json:"characters,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+// GrammarNotes are the notes of a GRAMMAR log
+type GrammarNotes struct {
+	Source  string `json:"source,omitempty"`
+	Points  uint64 `json:"points,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// OtherNotes are the notes of an OTHER log
+type OtherNotes struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// newNotes returns empty notes of the shape belonging to an activity
+func newNotes(activity enums.Activity) Notes {
+	switch activity {
+	case enums.ActivityReading:
+		return &ReadingNotes{}
+	case enums.ActivityListening:
+		return &ListeningNotes{}
+	case enums.ActivityFlashcards:
+		return &FlashcardsNotes{}
+	case enums.ActivityTextbook:
+		return &TextbookNotes{}
+	case enums.ActivityTranslation:
+		return &TranslationNotes{}
+	case enums.ActivityGrammar:
+		return &GrammarNotes{}
+	case enums.ActivityOther:
+		return &OtherNotes{}
+	}
+
+	return nil
+}
+
+// ParseNotes decodes the notes of a log into the shape belonging to its activity,
+// returns nil when the log has no notes
+func ParseNotes(activity enums.Activity, notes types.JSONText) (Notes, error) {
+	if isEmptyNotes(notes) {
+		return nil, nil
+	}
+
+	parsedNotes := newNotes(activity)
+	if parsedNotes == nil {
+		return nil, fmt.Errorf("no notes exist for activity `%s`", activity)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(notes))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(parsedNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsedNotes, nil
+}
+
+// isEmptyNotes checks whether a log has no notes at all
+func isEmptyNotes(notes types.JSONText) bool {
+	trimmedNotes := bytes.TrimSpace(notes)
+
+	return len(trimmedNotes) == 0 || string(trimmedNotes) == "null"
+}
+
+// notesFields returns the names of the fields notes can have
+func notesFields(notes Notes) map[string]bool {
+	fields := make(map[string]bool)
+
+	notesType := reflect.TypeOf(notes).Elem()
+	for index := 0; index < notesType.NumField(); index++ {
+		name := strings.Split(notesType.Field(index).Tag.Get("json"), ",")[0]
+		fields[name] = true
+	}
+
+	return fields
+}
+
+// notesAmountRead returns the pages and characters read according to notes, 0 when they weren't tracked
+func notesAmountRead(activity enums.Activity, notes types.JSONText) (pages uint64, characters uint64) {
+	parsedNotes, err := ParseNotes(activity, notes)
+	if err != nil {
+		return 0, 0
+	}
+
+	switch typedNotes := parsedNotes.(type) {
+	case *ReadingNotes:
+		return typedNotes.Pages, typedNotes.Characters
+	case *TextbookNotes:
+		return typedNotes.Pages, 0
+	case *TranslationNotes:
+		return 0, typedNotes.Characters
+	}
+
+	return 0, 0
+}
+
+// validateNotes checks the notes of a log have the fields belonging to its activity
+func validateNotes(activity enums.Activity, notes types.JSONText, validationErrors *ValidationErrors) {
+	if !activity.IsValid() || isEmptyNotes(notes) {
+		return
+	}
+
+	// Look for unknown fields ourselves, the decoder only reports the first one and has no typed error for it
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(notes, &fields); err != nil {
+		validationErrors.Add("notes", "invalid `Notes` supplied, expected an object")
+		return
+	}
+
+	knownFields := notesFields(newNotes(activity))
+	unknownFields := make([]string, 0)
+	for field := range fields {
+		if !knownFields[field] {
+			unknownFields = append(unknownFields, field)
+		}
+	}
+	if len(unknownFields) > 0 {
+		sort.Strings(unknownFields)
+		for _, field := range unknownFields {
+			validationErrors.Add("notes."+field, fmt.Sprintf("unknown field for activity %s", activity))
+		}
+
+		return
+	}
+
+	parsedNotes, err := ParseNotes(activity, notes)
+	if err != nil {
+		if typedErr, ok := err.(*json.UnmarshalTypeError); ok && typedErr.Field != "" {
+			validationErrors.Add("notes."+typedErr.Field, fmt.Sprintf("invalid value supplied, expected %s", describeType(typedErr.Type)))
+		} else {
+			validationErrors.Add("notes", "invalid `Notes` supplied, expected an object")
+		}
+
+		return
+	}
+
+	parsedNotes.validate(validationErrors)
+}
+
+// describeType describes the kind of value expected for a field in notes
+func describeType(expected reflect.Type) string {
+	switch expected.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a positive whole number"
+	}
+
+	return expected.String()
+}
+
+// validateNotesText checks the length of a text field in notes
+func validateNotesText(validationErrors *ValidationErrors, field string, value string, maxLength int) {
+	if len([]rune(value)) > maxLength {
+		validationErrors.Add("notes."+field, fmt.Sprintf("can be at most %d characters", maxLength))
+	}
+}
+
+func (notes *ReadingNotes) validate(validationErrors *ValidationErrors) {
+	if notes.Type != "" && !notes.Type.IsValid() {
+		validationErrors.Add("notes.type", "invalid `Type` supplied")
+	}
+	validateNotesText(validationErrors, "series", notes.Series, maxNotesTextLength)
+	validateNotesText(validationErrors, "comment", notes.Comment, maxNotesCommentLength)
+}
+
+func (notes *ListeningNotes) validate(validationErrors *ValidationErrors) {
+	validateNotesText(validationErrors, "source", notes.Source, maxNotesTextLength)
+	validateNotesText(validationErrors, "comment", notes.Comment, maxNotesCommentLength)
+}
+
+func (notes *FlashcardsNotes) validate(validationErrors *ValidationErrors) {
+	validateNotesText(validationErrors, "deck", notes.Deck, maxNotesTextLength)
+	validateNotesText(validationErrors, "comment", notes.Comment, maxNotesCommentLength)
+}
+
+func (notes *TextbookNotes) validate(validationErrors *ValidationErrors) {
+	validateNotesText(validationErrors, "book", notes.Book, maxNotesTextLength)
+	validateNotesText(validationErrors, "chapter", notes.Chapter, maxNotesTextLength)
+	validateNotesText(validationErrors, "comment", notes.Comment, maxNotesCommentLength)
+}
+
+func (notes *TranslationNotes) validate(validationErrors *ValidationErrors) {
+	validateNotesText(validationErrors, "source", notes.Source, maxNotesTextLength)
+	validateNotesText(validationErrors, "comment", notes.Comment, maxNotesCommentLength)
+}
+
+func (notes *GrammarNotes) validate(validationErrors *ValidationErrors) {
+	validateNotesText(validationErrors, "source", notes.Source, maxNotesTextLength)
+	validateNotesText(validationErrors, "comment", notes.Comment, maxNotesCommentLength)
+}
+
+func (notes *OtherNotes) validate(validationErrors *ValidationErrors) {
+	validateNotesText(validationErrors, "comment", notes.Comment, maxNotesCommentLength)
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"strings"
+)
+
+// PomodoroDay tallies the pomodoro intervals of a single day
+type PomodoroDay struct {
+	Date string `json:"date" db:"date"`
+
+	// Completed is the amount of focus intervals that lasted as long as planned
+	Completed uint64 `json:"completed" db:"completed"`
+
+	// FocusDuration and BreakDuration are the minutes spent focusing and on breaks
+	FocusDuration uint64 `json:"focus_duration" db:"focus_duration"`
+	BreakDuration uint64 `json:"break_duration" db:"break_duration"`
+}
+
+// PomodoroTally tallies the pomodoro intervals of a user per day
+type PomodoroTally struct {
+	Days []PomodoroDay `json:"days"`
+}
+
+// pomodoroDateExpression is the day an interval started on in the timezone of the user
+const pomodoroDateExpression = `CAST(started_at AT TIME ZONE 'UTC' AT TIME ZONE ` + userTimezoneExpression + ` AS date)`
+
+// GetFromUser tallies the pomodoro intervals of a user that started between from and until, both are optional.
+// Intervals are tallied on the day they started in the timezone of the user.
+func (tally *PomodoroTally) GetFromUser(userID uint64, from string, until string) error {
+	db := GetDatabase()
+
+	filters := &LogFilters{From: from, Until: until}
+	err := filters.Validate()
+	if err != nil {
+		return err
+	}
+
+	conditions := []string{"user_id = :user_id"}
+	args := map[string]interface{}{"user_id": userID}
+	if from != "" {
+		conditions = append(conditions, pomodoroDateExpression+" >= CAST(:from AS date)")
+		args["from"] = from
+	}
+	if until != "" {
+		conditions = append(conditions, pomodoroDateExpression+" <= CAST(:until AS date)")
+		args["until"] = until
+	}
+
+	tally.Days = make([]PomodoroDay, 0)
+	rows, err := db.NamedQuery(`
+		SELECT
+			to_char(`+pomodoroDateExpression+`, 'YYYY-MM-DD') AS date,
+			COUNT(*) FILTER (WHERE completed) AS completed,
+			CAST(ROUND(COALESCE(SUM(duration) FILTER (WHERE phase = 'FOCUS'), 0) / 60.0) AS bigint) AS focus_duration,
+			CAST(ROUND(COALESCE(SUM(duration) FILTER (WHERE phase = 'BREAK'), 0) / 60.0) AS bigint) AS break_duration
+		FROM pomodoro_intervals
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY date
+		ORDER BY date
+	`, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day PomodoroDay
+		err = rows.StructScan(&day)
+		if err != nil {
+			return err
+		}
+
+		tally.Days = append(tally.Days, day)
+	}
+
+	return rows.Err()
+}
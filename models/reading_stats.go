@@ -0,0 +1,157 @@
+package models
+
+import (
+	"github.com/antonve/logger-api/models/enums"
+)
+
+// ReadingStatsIntervals are the periods reading stats can be grouped by
+var ReadingStatsIntervals = map[string]bool{
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
+// DefaultReadingStatsInterval is used when no interval is given
+const DefaultReadingStatsInterval = "week"
+
+// ReadingStats are the totals of a number of reading sessions
+type ReadingStats struct {
+	Sessions   uint64 `json:"sessions" db:"sessions"`
+	Duration   uint64 `json:"duration" db:"duration"`
+	Pages      uint64 `json:"pages" db:"pages"`
+	Characters uint64 `json:"characters" db:"characters"`
+
+	// CharactersPerMinute only counts sessions in which characters were tracked, not set when there are none
+	CharactersPerMinute *float64 `json:"characters_per_minute" db:"characters_per_minute"`
+}
+
+// ReadingProgress is how far a user got in a resource
+type ReadingProgress struct {
+	ResourceID uint64  `json:"resource_id"`
+	TotalPages *uint64 `json:"total_pages"`
+
+	// Completion is the percentage of pages read, not set when the resource has no total
+	Completion *float64 `json:"completion"`
+
+	ReadingStats
+}
+
+// ReadingSpeedPeriod are the reading stats of a single day, week or month
+type ReadingSpeedPeriod struct {
+	// Period is the first day of the period
+	Period string `json:"period" db:"period"`
+
+	ReadingStats
+}
+
+// ReadingSpeedTrend are the reading stats of a user over time
+type ReadingSpeedTrend struct {
+	Interval string               `json:"interval"`
+	Periods  []ReadingSpeedPeriod `json:"periods"`
+}
+
+// readingStatsColumns aggregate the stats of the logs in the `reading_logs` sub query
+const readingStatsColumns = `
+			COUNT(*) AS sessions,
+			COALESCE(SUM(duration), 0) AS duration,
+			COALESCE(SUM(pages), 0) AS pages,
+			COALESCE(SUM(characters), 0) AS characters,
+			CAST(SUM(characters) AS float) / CAST(NULLIF(SUM(CASE WHEN characters > 0 THEN duration ELSE 0 END), 0) AS float) AS characters_per_minute`
+
+// readingLogsQuery selects the READING logs matching filters along with the amount read
+func readingLogsQuery(filters *LogFilters) (string, map[string]interface{}) {
+	readingFilters := *filters
+	readingFilters.Activities = []enums.Activity{enums.ActivityReading}
+	where, args := readingFilters.where()
+
+	return `
+		SELECT
+			date,
+			duration,
+			COALESCE(pages_read, 0) AS pages,
+			COALESCE(characters_read, 0) AS characters
+		FROM logs
+		WHERE ` + where, args
+}
+
+// GetReadingProgress returns how much of a resource its user has read
+func (resource *Resource) GetReadingProgress() (*ReadingProgress, error) {
+	db := GetDatabase()
+
+	query, args := readingLogsQuery(&LogFilters{UserID: resource.UserID, ResourceID: resource.ID})
+	progress := &ReadingProgress{ResourceID: resource.ID, TotalPages: resource.TotalPages}
+
+	rows, err := db.NamedQuery(`
+		SELECT `+readingStatsColumns+`
+		FROM (`+query+`) AS reading_logs
+	`, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.StructScan(&progress.ReadingStats)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resource.TotalPages != nil {
+		completion := float64(progress.Pages) / float64(*resource.TotalPages) * 100
+		if completion > 100 {
+			completion = 100
+		}
+		progress.Completion = &completion
+	}
+
+	return progress, rows.Err()
+}
+
+// GetFromUser returns the reading stats of a user per interval for the logs matching filters
+func (trend *ReadingSpeedTrend) GetFromUser(filters *LogFilters, interval string) error {
+	db := GetDatabase()
+
+	err := filters.Validate()
+	if err != nil {
+		return err
+	}
+	if interval == "" {
+		interval = DefaultReadingStatsInterval
+	}
+	if !ReadingStatsIntervals[interval] {
+		validationErrors := ValidationErrors{}
+		validationErrors.Add("interval", "invalid `Interval` supplied, must be one of day, week or month")
+		return validationErrors
+	}
+
+	query, args := readingLogsQuery(filters)
+	args["interval"] = interval
+
+	trend.Interval = interval
+	trend.Periods = make([]ReadingSpeedPeriod, 0)
+
+	rows, err := db.NamedQuery(`
+		SELECT
+			to_char(date_trunc(:interval, CAST(date AS timestamp)), 'YYYY-MM-DD') AS period,`+readingStatsColumns+`
+		FROM (`+query+`) AS reading_logs
+		GROUP BY period
+		ORDER BY period
+	`, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var period ReadingSpeedPeriod
+		err = rows.StructScan(&period)
+		if err != nil {
+			return err
+		}
+
+		trend.Periods = append(trend.Periods, period)
+	}
+
+	return rows.Err()
+}
@@ -1,7 +1,6 @@
 package models
 
 import (
-	"errors"
 	"fmt"
 	"time"
 
@@ -20,6 +19,9 @@ type JwtRefreshTokenClaims struct {
 	jwt.StandardClaims
 }
 
+// maxDeviceIDLength is the size of the `device_id` column
+const maxDeviceIDLength = 32
+
 // RefreshTokenCollection array of refresh tokens
 type RefreshTokenCollection struct {
 	RefreshTokens []RefreshToken `json:"refresh_tokens"`
@@ -93,26 +95,29 @@ func (refreshTokenCollection *RefreshTokenCollection) Length() int {
 
 // Validate the RefreshToken model
 func (refreshToken *RefreshToken) Validate() error {
+	validationErrors := ValidationErrors{}
+
 	if refreshToken.UserID == 0 {
-		return errors.New("invalid `UserID` supplied")
+		validationErrors.Add("user_id", "invalid `UserID` supplied")
 	}
 	if refreshToken.DeviceID == "" {
-		return errors.New("invalid `DeviceID` supplied")
+		validationErrors.Add("device_id", "invalid `DeviceID` supplied")
+	} else if len(refreshToken.DeviceID) > maxDeviceIDLength {
+		validationErrors.Add("device_id", fmt.Sprintf("invalid `DeviceID` supplied, can be at most %d characters", maxDeviceIDLength))
 	}
 	if refreshToken.CreatedAt.IsZero() {
-		return errors.New("invalid `CreatedAt` supplied")
+		validationErrors.Add("created_at", "invalid `CreatedAt` supplied")
 	}
 	if refreshToken.UpdatedAt.IsZero() {
-		return errors.New("invalid `UpdatedAt` supplied")
+		validationErrors.Add("updated_at", "invalid `UpdatedAt` supplied")
 	}
 
-	return nil
+	return validationErrors.Err()
 }
 
 // Get a refresh token by id
 func (refreshTokenCollection *RefreshTokenCollection) Get(id uint64) (*RefreshToken, error) {
 	db := GetDatabase()
-	defer db.Close()
 
 	// Init refresh token
 	refreshToken := RefreshToken{}
@@ -137,7 +142,7 @@ func (refreshTokenCollection *RefreshTokenCollection) Get(id uint64) (*RefreshTo
 
 	stmt.Get(&refreshToken, id)
 	if refreshToken.ID == 0 {
-		return nil, fmt.Errorf("no refresh token found with id %v", id)
+		return nil, &NotFoundError{Resource: "refresh token", ID: id}
 	}
 
 	return &refreshToken, nil
@@ -147,7 +152,6 @@ func (refreshTokenCollection *RefreshTokenCollection) Get(id uint64) (*RefreshTo
 // nil is returned when a token is invalidated
 func (refreshTokenCollection *RefreshTokenCollection) GetByClaims(claims *JwtRefreshTokenClaims) (*RefreshToken, error) {
 	db := GetDatabase()
-	defer db.Close()
 
 	// Init refresh token
 	refreshToken := RefreshToken{}
@@ -183,7 +187,6 @@ func (refreshTokenCollection *RefreshTokenCollection) GetByClaims(claims *JwtRef
 // Add a refresh token to the database
 func (refreshTokenCollection *RefreshTokenCollection) Add(refreshToken *RefreshToken) (uint64, error) {
 	db := GetDatabase()
-	defer db.Close()
 
 	// We must do the invalidation and creation of new tokens in a transaction
 	// to make sure we don't leave the DB in a bad state if we crash
@@ -0,0 +1,265 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/jmoiron/sqlx"
+)
+
+// ResourceCollection array of resources
+type ResourceCollection struct {
+	Resources []Resource `json:"resources"`
+}
+
+// Resource model, a book, show, podcast or deck a user studies with
+type Resource struct {
+	ID            uint64             `json:"id" db:"id"`
+	UserID        uint64             `json:"user_id" db:"user_id"`
+	Title         string             `json:"title" db:"title"`
+	Type          enums.ResourceType `json:"type" db:"type"`
+	Language      enums.Language     `json:"language" db:"language"`
+	TotalPages    *uint64            `json:"total_pages" db:"total_pages"`
+	TotalEpisodes *uint64            `json:"total_episodes" db:"total_episodes"`
+	TotalCards    *uint64            `json:"total_cards" db:"total_cards"`
+	CoverURL      *string            `json:"cover_url" db:"cover_url"`
+	ExternalURL   *string            `json:"external_url" db:"external_url"`
+	CreatedAt     time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// maxResourceTitleLength is the longest title a resource can have
+const maxResourceTitleLength = 255
+
+// maxResourceTotal is the largest amount of pages, episodes or cards a resource can have
+const maxResourceTotal = 1000000
+
+// resourceColumns are the columns selected for a Resource
+const resourceColumns = `
+			id,
+			user_id,
+			title,
+			type,
+			language,
+			total_pages,
+			total_episodes,
+			total_cards,
+			cover_url,
+			external_url,
+			created_at,
+			updated_at`
+
+// Length returns the amount of resources in the collection
+func (resourceCollection *ResourceCollection) Length() int {
+	return len(resourceCollection.Resources)
+}
+
+// Validate the Resource model
+func (resource *Resource) Validate() error {
+	validationErrors := ValidationErrors{}
+
+	if resource.UserID == 0 {
+		validationErrors.Add("user_id", "invalid `UserID` supplied")
+	}
+	if resource.Title == "" {
+		validationErrors.Add("title", "invalid `Title` supplied")
+	} else if len([]rune(resource.Title)) > maxResourceTitleLength {
+		validationErrors.Add("title", fmt.Sprintf("invalid `Title` supplied, can be at most %d characters", maxResourceTitleLength))
+	}
+	if len(resource.Type) == 0 || !resource.Type.IsValid() {
+		validationErrors.Add("type", "invalid `Type` supplied")
+	}
+	if len(resource.Language) == 0 || !isSupportedLanguage(resource.Language) {
+		validationErrors.Add("language", "invalid `Language` supplied")
+	}
+
+	totals := []struct {
+		field string
+		total *uint64
+	}{
+		{"total_pages", resource.TotalPages},
+		{"total_episodes", resource.TotalEpisodes},
+		{"total_cards", resource.TotalCards},
+	}
+	for _, total := range totals {
+		if total.total != nil && (*total.total == 0 || *total.total > maxResourceTotal) {
+			validationErrors.Add(total.field, fmt.Sprintf("invalid `%s` supplied, must be between 1 and %d", total.field, maxResourceTotal))
+		}
+	}
+
+	links := []struct {
+		field string
+		link  *string
+	}{
+		{"cover_url", resource.CoverURL},
+		{"external_url", resource.ExternalURL},
+	}
+	for _, link := range links {
+		if link.link != nil && !isWebURL(*link.link) {
+			validationErrors.Add(link.field, fmt.Sprintf("invalid `%s` supplied, expected a http or https URL", link.field))
+		}
+	}
+
+	return validationErrors.Err()
+}
+
+// isWebURL checks whether a link can be opened in a browser
+func isWebURL(link string) bool {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	return (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") && parsedURL.Host != ""
+}
+
+// IsOwner checks the owner
+func (resource *Resource) IsOwner(userID uint64) bool {
+	return resource.UserID == userID
+}
+
+// GetAllFromUser returns all resources from a certain user
+func (resourceCollection *ResourceCollection) GetAllFromUser(userID uint64) error {
+	db := GetDatabase()
+
+	err := db.Select(&resourceCollection.Resources, `
+		SELECT `+resourceColumns+`
+		FROM resources
+		WHERE user_id = $1
+		ORDER BY title, id
+	`, userID)
+
+	return err
+}
+
+// Get a resource by id
+func (resourceCollection *ResourceCollection) Get(id uint64) (*Resource, error) {
+	db := GetDatabase()
+
+	resource := Resource{}
+	err := db.Get(&resource, `
+		SELECT `+resourceColumns+`
+		FROM resources
+		WHERE id = $1
+	`, id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "resource", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &resource, nil
+}
+
+// Add a resource to the database
+func (resourceCollection *ResourceCollection) Add(resource *Resource) (uint64, error) {
+	db := GetDatabase()
+
+	stmt, err := db.PrepareNamed(`
+		INSERT INTO resources (user_id, title, type, language, total_pages, total_episodes, total_cards, cover_url, external_url)
+		VALUES (:user_id, :title, :type, :language, :total_pages, :total_episodes, :total_cards, :cover_url, :external_url)
+		RETURNING id
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	err = stmt.Get(&resource.ID, resource)
+	if err != nil {
+		return 0, err
+	}
+
+	return resource.ID, nil
+}
+
+// Update a resource
+func (resourceCollection *ResourceCollection) Update(resource *Resource) error {
+	db := GetDatabase()
+
+	result, err := db.NamedExec(`
+		UPDATE resources
+		SET
+			title = :title,
+			type = :type,
+			language = :language,
+			total_pages = :total_pages,
+			total_episodes = :total_episodes,
+			total_cards = :total_cards,
+			cover_url = :cover_url,
+			external_url = :external_url,
+			updated_at = (current_timestamp AT TIME ZONE 'UTC')
+		WHERE
+			id = :id AND
+			user_id = :user_id
+	`, resource)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err == nil && updated == 0 {
+		return &NotFoundError{Resource: "resource", ID: resource.ID}
+	}
+
+	return err
+}
+
+// Delete a resource, logs referencing it are kept but no longer linked to it. changedBy is the user deleting it
+func (resourceCollection *ResourceCollection) Delete(resource *Resource, changedBy uint64) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		err := unlinkLogs(tx, "resource_id", resource.ID, resource.UserID, changedBy)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.NamedExec(`
+			DELETE FROM resources
+			WHERE
+				id = :id AND
+				user_id = :user_id
+		`, resource)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err == nil && deleted == 0 {
+			return &NotFoundError{Resource: "resource", ID: resource.ID}
+		}
+
+		return err
+	})
+}
+
+// checkResourceOwner makes sure a log only references resources of its user
+func checkResourceOwner(tx *sqlx.Tx, log *Log) error {
+	if log.ResourceID == nil {
+		return nil
+	}
+
+	var exists bool
+	err := tx.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM resources
+			WHERE
+				id = $1 AND
+				user_id = $2
+		)
+	`, *log.ResourceID, log.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		validationErrors := ValidationErrors{}
+		validationErrors.Add("resource_id", "invalid `ResourceID` supplied")
+		return validationErrors
+	}
+
+	return nil
+}
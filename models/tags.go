@@ -0,0 +1,378 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// TagCollection array of tags
+type TagCollection struct {
+	Tags []Tag `json:"tags"`
+}
+
+// Tag model, a free-form label users put on their logs, e.g. `JLPT N2` or `commute`.
+// Tags are created when they're first used on a log, names are unique per user regardless of case.
+type Tag struct {
+	ID        uint64    `json:"id" db:"id"`
+	UserID    uint64    `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// Logs is the amount of logs with the tag, the trash excluded
+	Logs uint64 `json:"logs" db:"logs"`
+}
+
+// TagDuration is the time spent on logs with a tag
+type TagDuration struct {
+	TagID    uint64 `json:"tag_id" db:"tag_id"`
+	Name     string `json:"name" db:"name"`
+	Logs     uint64 `json:"logs" db:"logs"`
+	Duration uint64 `json:"duration" db:"duration"`
+}
+
+// TagDurationCollection is the time spent per tag, most time spent first
+type TagDurationCollection struct {
+	Tags []TagDuration `json:"tags"`
+}
+
+// maxTagNameLength is the longest name a tag can have
+const maxTagNameLength = 50
+
+// maxLogTags is the maximum amount of tags on a single log
+const maxLogTags = 20
+
+// tagColumns are the columns selected for a Tag
+const tagColumns = `
+			id,
+			user_id,
+			name,
+			created_at,
+			(
+				SELECT COUNT(*)
+				FROM log_tags
+				JOIN logs ON logs.id = log_tags.log_id
+				WHERE
+					log_tags.tag_id = tags.id AND
+					logs.deleted = FALSE
+			) AS logs`
+
+// logTagsColumn selects the names of the tags of a log, must be used in a query on `logs`
+const logTagsColumn = `
+			ARRAY(
+				SELECT tags.name
+				FROM log_tags
+				JOIN tags ON tags.id = log_tags.tag_id
+				WHERE log_tags.log_id = logs.id
+				ORDER BY lower(tags.name)
+			) AS tags`
+
+// Length returns the amount of tags in the collection
+func (tagCollection *TagCollection) Length() int {
+	return len(tagCollection.Tags)
+}
+
+// Validate the Tag model
+func (tag *Tag) Validate() error {
+	validationErrors := ValidationErrors{}
+
+	if tag.UserID == 0 {
+		validationErrors.Add("user_id", "invalid `UserID` supplied")
+	}
+	if message := validateTagName(tag.Name); message != "" {
+		validationErrors.Add("name", message)
+	}
+
+	return validationErrors.Err()
+}
+
+// validateTagName returns why a tag name is invalid, an empty string when it's valid
+func validateTagName(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return "invalid `Name` supplied"
+	}
+	if len([]rune(name)) > maxTagNameLength {
+		return fmt.Sprintf("invalid `Name` supplied, can be at most %d characters", maxTagNameLength)
+	}
+	// Commas separate tags in query parameters
+	if strings.Contains(name, ",") {
+		return "invalid `Name` supplied, can't contain commas"
+	}
+
+	return ""
+}
+
+// validateLogTags checks the tags of a log
+func validateLogTags(tags []string, validationErrors *ValidationErrors) {
+	if len(tags) > maxLogTags {
+		validationErrors.Add("tags", fmt.Sprintf("invalid `Tags` supplied, a log can have at most %d tags", maxLogTags))
+	}
+
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if message := validateTagName(tag); message != "" {
+			validationErrors.Add("tags", strings.Replace(message, "`Name`", fmt.Sprintf("tag `%s`", tag), 1))
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(tag))
+		if seen[key] {
+			validationErrors.Add("tags", fmt.Sprintf("invalid `Tags` supplied, `%s` is used more than once", tag))
+		}
+		seen[key] = true
+	}
+}
+
+// IsOwner checks the owner
+func (tag *Tag) IsOwner(userID uint64) bool {
+	return tag.UserID == userID
+}
+
+// GetAllFromUser returns all tags from a certain user
+func (tagCollection *TagCollection) GetAllFromUser(userID uint64) error {
+	db := GetDatabase()
+
+	err := db.Select(&tagCollection.Tags, `
+		SELECT `+tagColumns+`
+		FROM tags
+		WHERE user_id = $1
+		ORDER BY lower(name), id
+	`, userID)
+
+	return err
+}
+
+// Get a tag by id
+func (tagCollection *TagCollection) Get(id uint64) (*Tag, error) {
+	db := GetDatabase()
+
+	tag := Tag{}
+	err := db.Get(&tag, `
+		SELECT `+tagColumns+`
+		FROM tags
+		WHERE id = $1
+	`, id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "tag", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// Update renames a tag in its logs and templates, the logs show up in the change feed. changedBy is the user renaming it
+func (tagCollection *TagCollection) Update(tag *Tag, changedBy uint64) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		taggedLogs, err := getTaggedLogsForUpdate(tx, tag)
+		if err != nil {
+			return err
+		}
+
+		var exists bool
+		err = tx.Get(&exists, `
+			SELECT EXISTS (
+				SELECT 1
+				FROM tags
+				WHERE
+					user_id = $1 AND
+					lower(name) = lower($2) AND
+					id <> $3
+			)
+		`, tag.UserID, strings.TrimSpace(tag.Name), tag.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			validationErrors := ValidationErrors{}
+			validationErrors.Add("name", "invalid `Name` supplied, another tag already has this name")
+			return validationErrors
+		}
+
+		var oldName string
+		err = tx.Get(&oldName, `
+			SELECT name
+			FROM tags
+			WHERE
+				id = $1 AND
+				user_id = $2
+			FOR UPDATE
+		`, tag.ID, tag.UserID)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Resource: "tag", ID: tag.ID}
+		}
+		if err != nil {
+			return err
+		}
+
+		newName := strings.TrimSpace(tag.Name)
+		_, err = tx.Exec(`
+			UPDATE tags
+			SET name = $3
+			WHERE
+				id = $1 AND
+				user_id = $2
+		`, tag.ID, tag.UserID, newName)
+		if err != nil {
+			return err
+		}
+
+		err = replaceTemplateTag(tx, tag.UserID, oldName, &newName)
+		if err != nil {
+			return err
+		}
+
+		return recordLogChanges(tx, taggedLogs, changedBy)
+	})
+}
+
+// Delete a tag, it's removed from all logs and templates. changedBy is the user deleting it
+func (tagCollection *TagCollection) Delete(tag *Tag, changedBy uint64) error {
+	return inTransaction(func(tx *sqlx.Tx) error {
+		taggedLogs, err := getTaggedLogsForUpdate(tx, tag)
+		if err != nil {
+			return err
+		}
+
+		var name string
+		err = tx.Get(&name, `
+			DELETE FROM tags
+			WHERE
+				id = $1 AND
+				user_id = $2
+			RETURNING name
+		`, tag.ID, tag.UserID)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Resource: "tag", ID: tag.ID}
+		}
+		if err != nil {
+			return err
+		}
+
+		err = replaceTemplateTag(tx, tag.UserID, name, nil)
+		if err != nil {
+			return err
+		}
+
+		return recordLogChanges(tx, taggedLogs, changedBy)
+	})
+}
+
+// getTaggedLogsForUpdate gets the logs with a tag as they are before the tag changes and locks them
+func getTaggedLogsForUpdate(tx *sqlx.Tx, tag *Tag) ([]Log, error) {
+	return getLogsForUpdate(tx, tag.UserID, `
+			id IN (
+				SELECT log_id
+				FROM log_tags
+				WHERE tag_id = $2
+			)`, tag.ID)
+}
+
+// setLogTags replaces the tags of a log, tags that don't exist yet are created.
+// The tags are kept as they are when the log has none set at all.
+func setLogTags(tx *sqlx.Tx, log *Log) error {
+	if log.Tags == nil {
+		return nil
+	}
+
+	_, err := tx.Exec(`DELETE FROM log_tags WHERE log_id = $1`, log.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range log.Tags {
+		name = strings.TrimSpace(name)
+
+		_, err = tx.Exec(`
+			INSERT INTO tags (user_id, name)
+			VALUES ($1, $2)
+			ON CONFLICT (user_id, lower(name)) DO NOTHING
+		`, log.UserID, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO log_tags (log_id, tag_id)
+			SELECT $1, id
+			FROM tags
+			WHERE
+				user_id = $2 AND
+				lower(name) = lower($3)
+			ON CONFLICT DO NOTHING
+		`, log.ID, log.UserID, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetFromUser returns the time a user spent per tag on the logs matching filters
+func (tagDurationCollection *TagDurationCollection) GetFromUser(filters *LogFilters) error {
+	db := GetDatabase()
+
+	err := filters.Validate()
+	if err != nil {
+		return err
+	}
+
+	where, args := filters.where()
+
+	tagDurationCollection.Tags = make([]TagDuration, 0)
+	rows, err := db.NamedQuery(`
+		SELECT
+			tags.id AS tag_id,
+			tags.name,
+			COUNT(*) AS logs,
+			SUM(tagged_logs.duration) AS duration
+		FROM (
+			SELECT id, duration
+			FROM logs
+			WHERE `+where+`
+		) AS tagged_logs
+		JOIN log_tags ON log_tags.log_id = tagged_logs.id
+		JOIN tags ON tags.id = log_tags.tag_id
+		GROUP BY tags.id, tags.name
+		ORDER BY duration DESC, lower(tags.name)
+	`, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tagDuration TagDuration
+		err = rows.StructScan(&tagDuration)
+		if err != nil {
+			return err
+		}
+
+		tagDurationCollection.Tags = append(tagDurationCollection.Tags, tagDuration)
+	}
+
+	return rows.Err()
+}
+
+// tagsCondition matches logs with any of the tags, regardless of case
+func tagsCondition(tags []string) (string, interface{}) {
+	names := make([]string, len(tags))
+	for key, tag := range tags {
+		names[key] = strings.ToLower(strings.TrimSpace(tag))
+	}
+
+	return `EXISTS (
+			SELECT 1
+			FROM log_tags
+			JOIN tags ON tags.id = log_tags.tag_id
+			WHERE
+				log_tags.log_id = logs.id AND
+				lower(tags.name) = ANY(:tags)
+		)`, pq.Array(names)
+}
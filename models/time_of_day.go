@@ -0,0 +1,72 @@
+package models
+
+// TimeOfDayHour is the time spent on the sessions that started in an hour of the day
+type TimeOfDayHour struct {
+	Hour     uint64 `json:"hour" db:"hour"`
+	Logs     uint64 `json:"logs" db:"logs"`
+	Duration uint64 `json:"duration" db:"duration"`
+}
+
+// TimeOfDayStats is the time spent per hour of the day in the timezone of a user,
+// only logs with a start time are counted
+type TimeOfDayStats struct {
+	Timezone string          `json:"timezone"`
+	Hours    []TimeOfDayHour `json:"hours"`
+}
+
+// GetFromUser returns the time a user spent per hour of the day on the logs matching filters, all 24 hours are included
+func (timeOfDayStats *TimeOfDayStats) GetFromUser(filters *LogFilters) error {
+	db := GetDatabase()
+
+	err := filters.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = db.Get(&timeOfDayStats.Timezone, `
+		SELECT COALESCE(NULLIF(preferences ->> 'timezone', ''), $2)
+		FROM users
+		WHERE id = $1
+	`, filters.UserID, DefaultTimezone)
+	if err != nil {
+		return err
+	}
+
+	where, args := filters.where()
+
+	timeOfDayStats.Hours = make([]TimeOfDayHour, 0)
+	rows, err := db.NamedQuery(`
+		SELECT
+			hours.hour,
+			COUNT(timed_logs.id) AS logs,
+			COALESCE(SUM(timed_logs.duration), 0) AS duration
+		FROM generate_series(0, 23) AS hours(hour)
+		LEFT JOIN (
+			SELECT
+				id,
+				duration,
+				CAST(EXTRACT(hour FROM started_at AT TIME ZONE 'UTC' AT TIME ZONE `+userTimezoneExpression+`) AS int) AS hour
+			FROM logs
+			WHERE
+				started_at IS NOT NULL AND `+where+`
+		) AS timed_logs ON timed_logs.hour = hours.hour
+		GROUP BY hours.hour
+		ORDER BY hours.hour
+	`, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hour TimeOfDayHour
+		err = rows.StructScan(&hour)
+		if err != nil {
+			return err
+		}
+
+		timeOfDayStats.Hours = append(timeOfDayStats.Hours, hour)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,478 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antonve/logger-api/models/enums"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Timer is a study session that is being timed, every user has at most one timer.
+// It's kept on the server so it survives app restarts and shows up on every device.
+type Timer struct {
+	UserID     uint64          `json:"user_id" db:"user_id"`
+	Language   enums.Language  `json:"language" db:"language"`
+	Activity   enums.Activity  `json:"activity" db:"activity"`
+	Notes      types.JSONText  `json:"notes" db:"notes"`
+	ResourceID *uint64         `json:"resource_id" db:"resource_id"`
+	Mode       enums.TimerMode `json:"mode" db:"mode"`
+	StartedAt  time.Time       `json:"started_at" db:"started_at"`
+
+	// ResumedAt is when the timer last started running, not set while it's paused
+	ResumedAt *time.Time `json:"resumed_at" db:"resumed_at"`
+
+	// Elapsed is the amount of seconds the timer has been running, pauses excluded.
+	// Pomodoro timers only count the current focus interval or break.
+	Elapsed uint64 `json:"elapsed" db:"elapsed"`
+	Running bool   `json:"running" db:"running"`
+
+	// WasPaused is set when the timer was paused since the current focus interval or break started
+	WasPaused bool `json:"was_paused" db:"was_paused"`
+
+	// Pomodoro timers alternate between focus intervals and breaks, only focus intervals are logged.
+	// The lengths of the intervals are only set for pomodoro timers.
+	Phase          enums.TimerPhase `json:"phase" db:"phase"`
+	PhaseStartedAt time.Time        `json:"phase_started_at" db:"phase_started_at"`
+	FocusMinutes   *uint64          `json:"focus_minutes" db:"focus_minutes"`
+	BreakMinutes   *uint64          `json:"break_minutes" db:"break_minutes"`
+
+	// Pomodoros is the amount of focus intervals completed since the timer was started
+	Pomodoros uint64 `json:"pomodoros" db:"pomodoros"`
+}
+
+// Lengths of pomodoro intervals in minutes
+const (
+	DefaultFocusMinutes = 25
+	DefaultBreakMinutes = 5
+	maxFocusMinutes     = 120
+	maxBreakMinutes     = 60
+)
+
+// TimerStop are the optional changes made to the log created when a timer is stopped
+type TimerStop struct {
+	// Date defaults to the day the timer was started in the timezone of the user.
+	// Logs moved to another day don't keep when the timer ran as it would contradict their date.
+	Date string `json:"date"`
+
+	// Notes replace the notes of the timer when set, e.g. to add the amount of pages read
+	Notes types.JSONText `json:"notes"`
+}
+
+// Errors returned when a timer can't be changed in its current state
+var (
+	ErrTimerNotFound       = errors.New("no timer is running")
+	ErrTimerAlreadyStarted = errors.New("a timer was already started, stop it before starting a new one")
+	ErrTimerNotRunning     = errors.New("timer is already paused")
+	ErrTimerNotPaused      = errors.New("timer is already running")
+	ErrTimerNotPomodoro    = errors.New("only pomodoro timers take breaks")
+	ErrTimerOnBreak        = errors.New("timer is already on a break")
+	ErrTimerNotOnBreak     = errors.New("timer isn't on a break")
+)
+
+// timerElapsedExpression counts the seconds a timer ran before it was paused and since it was last resumed
+const timerElapsedExpression = `CAST(elapsed_seconds + COALESCE(FLOOR(EXTRACT(EPOCH FROM (current_timestamp AT TIME ZONE 'UTC') - resumed_at)), 0) AS bigint)`
+
+// timerColumns are the columns selected for a Timer
+const timerColumns = `
+			user_id,
+			language,
+			activity,
+			notes,
+			resource_id,
+			mode,
+			started_at,
+			resumed_at,
+			` + timerElapsedExpression + ` AS elapsed,
+			resumed_at IS NOT NULL AS running,
+			was_paused,
+			phase,
+			phase_started_at,
+			focus_minutes,
+			break_minutes,
+			pomodoros`
+
+// Validate the Timer model
+func (timer *Timer) Validate() error {
+	validationErrors := ValidationErrors{}
+
+	if timer.UserID == 0 {
+		validationErrors.Add("user_id", "invalid `UserID` supplied")
+	}
+	if len(timer.Language) == 0 || !isSupportedLanguage(timer.Language) {
+		validationErrors.Add("language", "invalid `Language` supplied")
+	}
+	if len(timer.Activity) == 0 || !timer.Activity.IsValid() {
+		validationErrors.Add("activity", "invalid `Activity` supplied")
+	}
+	validateNotes(timer.Activity, timer.Notes, &validationErrors)
+	if timer.ResourceID != nil && *timer.ResourceID == 0 {
+		validationErrors.Add("resource_id", "invalid `ResourceID` supplied")
+	}
+	if len(timer.Mode) != 0 && !timer.Mode.IsValid() {
+		validationErrors.Add("mode", "invalid `Mode` supplied")
+	}
+
+	lengths := []struct {
+		field   string
+		minutes *uint64
+		max     uint64
+	}{
+		{"focus_minutes", timer.FocusMinutes, maxFocusMinutes},
+		{"break_minutes", timer.BreakMinutes, maxBreakMinutes},
+	}
+	for _, length := range lengths {
+		if length.minutes == nil {
+			continue
+		}
+		if timer.Mode != enums.TimerModePomodoro {
+			validationErrors.Add(length.field, fmt.Sprintf("`%s` can only be set for pomodoro timers", length.field))
+		} else if *length.minutes == 0 || *length.minutes > length.max {
+			validationErrors.Add(length.field, fmt.Sprintf("invalid `%s` supplied, must be between 1 and %d", length.field, length.max))
+		}
+	}
+
+	return validationErrors.Err()
+}
+
+// GetFromUser loads the timer of a user
+func (timer *Timer) GetFromUser(userID uint64) error {
+	db := GetDatabase()
+
+	err := db.Get(timer, `
+		SELECT `+timerColumns+`
+		FROM timers
+		WHERE user_id = $1
+	`, userID)
+	if err == sql.ErrNoRows {
+		return ErrTimerNotFound
+	}
+
+	return err
+}
+
+// Start the timer, fails when the user already has one
+func (timer *Timer) Start() error {
+	if len(timer.Mode) == 0 {
+		timer.Mode = enums.TimerModeStandard
+	}
+	if timer.Mode == enums.TimerModePomodoro {
+		if timer.FocusMinutes == nil {
+			focusMinutes := uint64(DefaultFocusMinutes)
+			timer.FocusMinutes = &focusMinutes
+		}
+		if timer.BreakMinutes == nil {
+			breakMinutes := uint64(DefaultBreakMinutes)
+			timer.BreakMinutes = &breakMinutes
+		}
+	}
+
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		err := checkResourceOwner(tx, &Log{UserID: timer.UserID, ResourceID: timer.ResourceID})
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.NamedExec(`
+			INSERT INTO timers (user_id, language, activity, notes, resource_id, mode, focus_minutes, break_minutes)
+			VALUES (:user_id, :language, :activity, :notes, :resource_id, :mode, :focus_minutes, :break_minutes)
+			ON CONFLICT (user_id) DO NOTHING
+		`, timer)
+		if err != nil {
+			return err
+		}
+
+		started, err := result.RowsAffected()
+		if err == nil && started == 0 {
+			return ErrTimerAlreadyStarted
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return timer.GetFromUser(timer.UserID)
+}
+
+// Pause the timer of a user, the time it ran so far is kept
+func (timer *Timer) Pause(userID uint64) error {
+	return timer.change(userID, `
+		UPDATE timers
+		SET
+			elapsed_seconds = `+timerElapsedExpression+`,
+			resumed_at = NULL,
+			was_paused = TRUE
+		WHERE
+			user_id = $1 AND
+			resumed_at IS NOT NULL
+	`, ErrTimerNotRunning)
+}
+
+// Resume the paused timer of a user
+func (timer *Timer) Resume(userID uint64) error {
+	return timer.change(userID, `
+		UPDATE timers
+		SET resumed_at = (current_timestamp AT TIME ZONE 'UTC')
+		WHERE
+			user_id = $1 AND
+			resumed_at IS NULL
+	`, ErrTimerNotPaused)
+}
+
+// change runs an update on the timer of a user and loads the result,
+// stateErr is returned when the timer exists but the update didn't apply to it
+func (timer *Timer) change(userID uint64, query string, stateErr error) error {
+	db := GetDatabase()
+
+	result, err := db.Exec(query, userID)
+	if err != nil {
+		return err
+	}
+
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	err = timer.GetFromUser(userID)
+	if err == nil && changed == 0 {
+		return stateErr
+	}
+
+	return err
+}
+
+// Stop the timer of a user and log the time it ran rounded to minutes, returns the log as it was stored.
+// No log is returned when the timer is stopped before it ran for a minute. Pomodoro timers only log the
+// current focus interval so no log is returned either when they're stopped on a break.
+func (timer *Timer) Stop(userID uint64, stop *TimerStop) (*Log, error) {
+	var newLog *Log
+
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		err := getTimerForUpdate(tx, timer, userID)
+		if err != nil {
+			return err
+		}
+
+		if timer.Mode == enums.TimerModePomodoro {
+			newLog, err = endPomodoroInterval(tx, timer, stop, userID)
+			if err != nil {
+				return err
+			}
+		} else {
+			log := timer.newLog(stop)
+			if log.Duration > 0 {
+				err = log.Validate()
+				if err != nil {
+					return err
+				}
+
+				newLog, err = addLog(tx, log, userID)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err = tx.Exec(`DELETE FROM timers WHERE user_id = $1`, userID)
+		return err
+	})
+
+	return newLog, err
+}
+
+// Break ends the focus interval of a pomodoro timer and starts a break, returns the log of the focus interval.
+// No log is returned when the break is taken before a minute of the interval passed.
+func (timer *Timer) Break(userID uint64, stop *TimerStop) (*Log, error) {
+	var newLog *Log
+
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		err := getTimerForUpdate(tx, timer, userID)
+		if err != nil {
+			return err
+		}
+		if timer.Mode != enums.TimerModePomodoro {
+			return ErrTimerNotPomodoro
+		}
+		if timer.Phase == enums.TimerPhaseBreak {
+			return ErrTimerOnBreak
+		}
+
+		newLog, err = endPomodoroInterval(tx, timer, stop, userID)
+		if err != nil {
+			return err
+		}
+
+		return startPomodoroInterval(tx, timer, enums.TimerPhaseBreak)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newLog, timer.GetFromUser(userID)
+}
+
+// Focus ends the break of a pomodoro timer and starts the next focus interval
+func (timer *Timer) Focus(userID uint64) error {
+	err := inTransaction(func(tx *sqlx.Tx) error {
+		err := getTimerForUpdate(tx, timer, userID)
+		if err != nil {
+			return err
+		}
+		if timer.Mode != enums.TimerModePomodoro {
+			return ErrTimerNotPomodoro
+		}
+		if timer.Phase != enums.TimerPhaseBreak {
+			return ErrTimerNotOnBreak
+		}
+
+		_, err = endPomodoroInterval(tx, timer, nil, userID)
+		if err != nil {
+			return err
+		}
+
+		return startPomodoroInterval(tx, timer, enums.TimerPhaseFocus)
+	})
+	if err != nil {
+		return err
+	}
+
+	return timer.GetFromUser(userID)
+}
+
+// newLog builds the log of the time the timer ran, it's dated on the day the timer started in the timezone of the user.
+// At most MaxLogDuration is logged, timers that ran longer are logged as if they stopped then.
+// Timers that were paused don't get an end time as the session didn't end the time they ran after they started.
+func (timer *Timer) newLog(stop *TimerStop) *Log {
+	elapsed := timer.Elapsed
+	if elapsed > MaxLogDuration*60 {
+		elapsed = MaxLogDuration * 60
+	}
+
+	startedAt := timer.PhaseStartedAt
+	var endedAt *time.Time
+	if !timer.WasPaused {
+		phaseEndedAt := startedAt.Add(time.Duration(elapsed) * time.Second)
+		endedAt = &phaseEndedAt
+	}
+	log := &Log{
+		UserID:     timer.UserID,
+		Language:   timer.Language,
+		Date:       stop.Date,
+		Duration:   (elapsed + 30) / 60,
+		Activity:   timer.Activity,
+		Notes:      timer.Notes,
+		StartedAt:  &startedAt,
+		EndedAt:    endedAt,
+		ResourceID: timer.ResourceID,
+	}
+	if len(stop.Notes) > 0 {
+		log.Notes = stop.Notes
+	}
+	if len(stop.Date) > 0 {
+		log.StartedAt = nil
+		log.EndedAt = nil
+	}
+
+	return log
+}
+
+// getTimerForUpdate loads and locks the timer of a user
+func getTimerForUpdate(tx *sqlx.Tx, timer *Timer, userID uint64) error {
+	err := tx.Get(timer, `
+		SELECT `+timerColumns+`
+		FROM timers
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID)
+	if err == sql.ErrNoRows {
+		return ErrTimerNotFound
+	}
+
+	return err
+}
+
+// endPomodoroInterval records the current interval of a pomodoro timer, changedBy is the user ending it.
+// Focus intervals of at least a minute are logged and count as a pomodoro when they lasted as long as planned
+func endPomodoroInterval(tx *sqlx.Tx, timer *Timer, stop *TimerStop, changedBy uint64) (*Log, error) {
+	var newLog *Log
+	var err error
+
+	completed := false
+	if timer.Phase == enums.TimerPhaseFocus {
+		completed = timer.FocusMinutes != nil && timer.Elapsed >= *timer.FocusMinutes*60
+
+		if stop == nil {
+			stop = &TimerStop{}
+		}
+		log := timer.newLog(stop)
+		if log.Duration > 0 {
+			err = log.Validate()
+			if err != nil {
+				return nil, err
+			}
+
+			newLog, err = addLog(tx, log, changedBy)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var logID *uint64
+	if newLog != nil {
+		logID = &newLog.ID
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO pomodoro_intervals (user_id, phase, started_at, ended_at, duration, completed, log_id)
+		VALUES ($1, $2, $3, (current_timestamp AT TIME ZONE 'UTC'), $4, $5, $6)
+	`, timer.UserID, timer.Phase, timer.PhaseStartedAt, timer.Elapsed, completed, logID)
+	if err != nil {
+		return nil, err
+	}
+
+	if completed {
+		_, err = tx.Exec(`UPDATE timers SET pomodoros = pomodoros + 1 WHERE user_id = $1`, timer.UserID)
+	}
+
+	return newLog, err
+}
+
+// startPomodoroInterval switches a pomodoro timer to the next phase, the new interval starts running right away
+func startPomodoroInterval(tx *sqlx.Tx, timer *Timer, phase enums.TimerPhase) error {
+	_, err := tx.Exec(`
+		UPDATE timers
+		SET
+			phase = $2,
+			phase_started_at = (current_timestamp AT TIME ZONE 'UTC'),
+			resumed_at = (current_timestamp AT TIME ZONE 'UTC'),
+			elapsed_seconds = 0,
+			was_paused = FALSE
+		WHERE user_id = $1
+	`, timer.UserID, phase)
+
+	return err
+}
+
+// Discard the timer of a user without logging it
+func (timer *Timer) Discard(userID uint64) error {
+	db := GetDatabase()
+
+	result, err := db.Exec(`DELETE FROM timers WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err == nil && deleted == 0 {
+		return ErrTimerNotFound
+	}
+
+	return err
+}
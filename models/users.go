@@ -1,14 +1,15 @@
 package models
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"reflect"
 
 	"github.com/antonve/logger-api/models/enums"
 	"github.com/badoux/checkmail"
+	"github.com/jmoiron/sqlx"
 
 	"golang.org/x/crypto/bcrypt"
 
@@ -25,7 +26,7 @@ type User struct {
 	ID          uint64      `json:"id" db:"id"`
 	Email       string      `json:"email" db:"email"`
 	DisplayName string      `json:"display_name" db:"display_name"`
-	Password    string      `json:"password" db:"password"`
+	Password    string      `json:"password,omitempty" db:"password"`
 	Role        enums.Role  `json:"role" db:"role"`
 	Preferences Preferences `json:"preferences" db:"preferences"`
 }
@@ -34,8 +35,21 @@ type User struct {
 type Preferences struct {
 	Languages     []enums.Language `json:"languages" db:"languages"`
 	PublicProfile bool             `json:"public_profile" db:"public_profile"`
+
+	// Timezone is an IANA timezone such as `Asia/Tokyo`, the dates of logs are derived in it. Defaults to UTC.
+	Timezone string `json:"timezone,omitempty" db:"timezone"`
 }
 
+// DefaultTimezone is used for users that didn't set a timezone
+const DefaultTimezone = "UTC"
+
+// userTimezoneExpression selects the timezone of the user a row belongs to, must be used in a query on a table with a `user_id` column
+const userTimezoneExpression = `COALESCE((
+				SELECT NULLIF(users.preferences ->> 'timezone', '')
+				FROM users
+				WHERE users.id = user_id
+			), '` + DefaultTimezone + `')`
+
 // Value of preferences (support for embedded preferences)
 func (preferences Preferences) Value() (driver.Value, error) {
 	return json.Marshal(preferences)
@@ -71,23 +85,68 @@ func (userCollection *UserCollection) Length() int {
 
 // Validate the User model
 func (user *User) Validate() error {
+	validationErrors := ValidationErrors{}
+
 	if len(user.Email) == 0 {
-		return errors.New("no `email` supplied")
-	}
-	if err := checkmail.ValidateFormat(user.Email); err != nil {
-		return errors.New("invalid `Email` supplied")
+		validationErrors.Add("email", "no `email` supplied")
+	} else if err := checkmail.ValidateFormat(user.Email); err != nil {
+		validationErrors.Add("email", "invalid `Email` supplied")
 	}
 	if len(user.DisplayName) == 0 {
-		return errors.New("invalid `DisplayName` supplied")
+		validationErrors.Add("display_name", "invalid `DisplayName` supplied")
 	}
 	if len(user.Role) == 0 || !user.Role.IsValid() {
-		return errors.New("invalid `Role` supplied")
+		validationErrors.Add("role", "invalid `Role` supplied")
 	}
 	if user.ID == 0 && len(user.Password) == 0 {
-		return errors.New("invalid `Password` supplied")
+		validationErrors.Add("password", "invalid `Password` supplied")
 	}
+	user.Preferences.validate(&validationErrors)
 
-	return nil
+	return validationErrors.Err()
+}
+
+// validate the preferences, failures are added to the errors of the user they belong to
+func (preferences *Preferences) validate(validationErrors *ValidationErrors) {
+	for key, language := range preferences.Languages {
+		if !isSupportedLanguage(language) {
+			validationErrors.Add(fmt.Sprintf("preferences.languages[%d]", key), fmt.Sprintf("invalid `Language` %s supplied", language))
+		}
+	}
+	if preferences.Timezone != "" && !isSupportedTimezone(preferences.Timezone) {
+		validationErrors.Add("preferences.timezone", fmt.Sprintf("invalid `Timezone` %s supplied", preferences.Timezone))
+	}
+}
+
+// getUserToday returns the current date in the timezone of a user
+func getUserToday(queryer sqlx.Queryer, userID uint64) (string, error) {
+	var today string
+	err := sqlx.Get(queryer, &today, `
+		SELECT to_char(current_timestamp AT TIME ZONE COALESCE(NULLIF(preferences ->> 'timezone', ''), $2), 'YYYY-MM-DD')
+		FROM users
+		WHERE id = $1
+	`, userID, DefaultTimezone)
+	if err == sql.ErrNoRows {
+		return "", &NotFoundError{Resource: "user", ID: userID}
+	}
+
+	return today, err
+}
+
+// isSupportedTimezone checks whether the database knows a timezone, dates are converted to the timezone of a user there
+func isSupportedTimezone(timezone string) bool {
+	db := GetDatabase()
+
+	var exists bool
+	err := db.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM pg_timezone_names
+			WHERE name = $1
+		)
+	`, timezone)
+
+	return err == nil && exists
 }
 
 // HashPassword hash the currently set password
@@ -104,7 +163,6 @@ func (user *User) HashPassword() error {
 // GetAll returns all users
 func (userCollection *UserCollection) GetAll() error {
 	db := GetDatabase()
-	defer db.Close()
 
 	err := db.Select(&userCollection.Users, `
 		SELECT
@@ -121,7 +179,6 @@ func (userCollection *UserCollection) GetAll() error {
 // Get a user by id
 func (userCollection *UserCollection) Get(id uint64) (*User, error) {
 	db := GetDatabase()
-	defer db.Close()
 
 	// Init user
 	user := User{}
@@ -138,6 +195,9 @@ func (userCollection *UserCollection) Get(id uint64) (*User, error) {
 		WHERE
 			id = $1
 	`, id).StructScan(&user)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "user", ID: id}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +208,6 @@ func (userCollection *UserCollection) Get(id uint64) (*User, error) {
 // GetAuthenticationData get data needed to generate jwt token
 func (userCollection *UserCollection) GetAuthenticationData(email string) (*User, error) {
 	db := GetDatabase()
-	defer db.Close()
 
 	user := User{}
 
@@ -174,7 +233,6 @@ func (userCollection *UserCollection) GetAuthenticationData(email string) (*User
 // Add a user to the database
 func (userCollection *UserCollection) Add(user *User) (uint64, error) {
 	db := GetDatabase()
-	defer db.Close()
 
 	query := `
 		INSERT INTO users
@@ -200,7 +258,6 @@ func (userCollection *UserCollection) Add(user *User) (uint64, error) {
 // Update a user
 func (userCollection *UserCollection) Update(user *User) error {
 	db := GetDatabase()
-	defer db.Close()
 
 	query := `
 		UPDATE users
@@ -218,7 +275,7 @@ func (userCollection *UserCollection) Update(user *User) error {
 
 	rows, err := result.RowsAffected()
 	if rows == 0 {
-		err = fmt.Errorf("No user found with id %v", user.ID)
+		err = &NotFoundError{Resource: "user", ID: user.ID}
 	}
 
 	return err
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+)
+
+// trashPurgeInterval is how often we look for logs that have been in the trash for too long
+const trashPurgeInterval = time.Hour
+
+// StartTrashPurger periodically hard-deletes logs that have been in the trash longer than the
+// configured retention period along with expired idempotency keys, returns a function that stops it and waits for a running purge
+func StartTrashPurger(e *echo.Echo) func() {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			purgeTrash(e)
+			purgeIdempotencyKeys(e)
+
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(quit)
+		<-done
+	}
+}
+
+func purgeTrash(e *echo.Echo) {
+	logCollection := models.LogCollection{}
+	purged, err := logCollection.PurgeDeleted(config.GetConfig().GetTrashRetention())
+	if err != nil {
+		e.Logger.Errorj(gommonLog.JSON{"task": "purge_trash", "error": err.Error()})
+		return
+	}
+
+	e.Logger.Infoj(gommonLog.JSON{"task": "purge_trash", "purged": purged})
+}
+
+func purgeIdempotencyKeys(e *echo.Echo) {
+	idempotencyKeyCollection := models.IdempotencyKeyCollection{}
+	purged, err := idempotencyKeyCollection.PurgeExpired(config.GetConfig().GetIdempotencyKeyTTL())
+	if err != nil {
+		e.Logger.Errorj(gommonLog.JSON{"task": "purge_idempotency_keys", "error": err.Error()})
+		return
+	}
+
+	e.Logger.Infoj(gommonLog.JSON{"task": "purge_idempotency_keys", "purged": purged})
+}
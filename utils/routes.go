@@ -11,12 +11,17 @@ import (
 
 // SetupRouting Define all routes here
 func SetupRouting(e *echo.Echo) {
+	// Serve all errors in the same format
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+
 	// Middleware
 	authenticated := middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtClaims{}))
 	authenticatedWithRefreshToken := middleware.JWTWithConfig(config.GetJWTConfig(&models.JwtRefreshTokenClaims{}))
 
 	// Routes
 	routesAPI := e.Group("/api")
+	routesAPI.GET("/openapi.json", echo.HandlerFunc(controllers.APIOpenAPISpec))
+	routesAPI.GET("/languages", echo.HandlerFunc(controllers.APILanguagesGetAll))
 	routesAPI.POST("/login", echo.HandlerFunc(controllers.APISessionLogin))
 	routesAPI.POST("/register", echo.HandlerFunc(controllers.APISessionRegister))
 
@@ -25,15 +30,73 @@ func SetupRouting(e *echo.Echo) {
 	routesSessions.POST("/authenticate", authenticatedWithRefreshToken(echo.HandlerFunc(controllers.APISessionAuthenticateWithRefreshToken)))
 
 	routesLogs := routesAPI.Group("/logs")
-	routesLogs.Use(authenticated)
+	routesLogs.Use(authenticated, controllers.Idempotent)
 	routesLogs.GET("", echo.HandlerFunc(controllers.APILogsGetAll))
 	routesLogs.POST("", echo.HandlerFunc(controllers.APILogsPost))
+	routesLogs.GET("/changes", echo.HandlerFunc(controllers.APILogsGetChanges))
+	routesLogs.GET("/search", echo.HandlerFunc(controllers.APILogsSearch))
+	routesLogs.GET("/duplicates", echo.HandlerFunc(controllers.APILogsGetDuplicates))
+	routesLogs.GET("/trash", echo.HandlerFunc(controllers.APILogsGetTrash))
+	routesLogs.POST("/batch", echo.HandlerFunc(controllers.APILogsBatch))
 	routesLogs.GET("/:id", echo.HandlerFunc(controllers.APILogsGetByID))
 	routesLogs.PUT("/:id", echo.HandlerFunc(controllers.APILogsUpdate))
 	routesLogs.DELETE("/:id", echo.HandlerFunc(controllers.APILogsDelete))
+	routesLogs.POST("/:id/restore", echo.HandlerFunc(controllers.APILogsRestore))
+	routesLogs.GET("/:id/history", echo.HandlerFunc(controllers.APILogsGetHistory))
+
+	routesResources := routesAPI.Group("/resources")
+	routesResources.Use(authenticated, controllers.Idempotent)
+	routesResources.GET("", echo.HandlerFunc(controllers.APIResourcesGetAll))
+	routesResources.POST("", echo.HandlerFunc(controllers.APIResourcesPost))
+	routesResources.GET("/:id", echo.HandlerFunc(controllers.APIResourcesGetByID))
+	routesResources.PUT("/:id", echo.HandlerFunc(controllers.APIResourcesUpdate))
+	routesResources.DELETE("/:id", echo.HandlerFunc(controllers.APIResourcesDelete))
+	routesResources.GET("/:id/progress", echo.HandlerFunc(controllers.APIResourcesGetProgress))
+
+	routesActivities := routesAPI.Group("/activities")
+	routesActivities.Use(authenticated, controllers.Idempotent)
+	routesActivities.GET("", echo.HandlerFunc(controllers.APIActivitiesGetAll))
+	routesActivities.POST("", echo.HandlerFunc(controllers.APIActivitiesPost))
+	routesActivities.GET("/:id", echo.HandlerFunc(controllers.APIActivitiesGetByID))
+	routesActivities.PUT("/:id", echo.HandlerFunc(controllers.APIActivitiesUpdate))
+	routesActivities.DELETE("/:id", echo.HandlerFunc(controllers.APIActivitiesDelete))
+
+	routesTemplates := routesAPI.Group("/templates")
+	routesTemplates.Use(authenticated, controllers.Idempotent)
+	routesTemplates.GET("", echo.HandlerFunc(controllers.APITemplatesGetAll))
+	routesTemplates.POST("", echo.HandlerFunc(controllers.APITemplatesPost))
+	routesTemplates.GET("/planned", echo.HandlerFunc(controllers.APITemplatesGetPlanned))
+	routesTemplates.GET("/:id", echo.HandlerFunc(controllers.APITemplatesGetByID))
+	routesTemplates.PUT("/:id", echo.HandlerFunc(controllers.APITemplatesUpdate))
+	routesTemplates.DELETE("/:id", echo.HandlerFunc(controllers.APITemplatesDelete))
+	routesTemplates.POST("/:id/logs", echo.HandlerFunc(controllers.APITemplatesPostLog))
+
+	routesTags := routesAPI.Group("/tags")
+	routesTags.Use(authenticated, controllers.Idempotent)
+	routesTags.GET("", echo.HandlerFunc(controllers.APITagsGetAll))
+	routesTags.PUT("/:id", echo.HandlerFunc(controllers.APITagsUpdate))
+	routesTags.DELETE("/:id", echo.HandlerFunc(controllers.APITagsDelete))
+
+	routesTimers := routesAPI.Group("/timers")
+	routesTimers.Use(authenticated, controllers.Idempotent)
+	routesTimers.POST("", echo.HandlerFunc(controllers.APITimersStart))
+	routesTimers.GET("/current", echo.HandlerFunc(controllers.APITimersGetCurrent))
+	routesTimers.DELETE("/current", echo.HandlerFunc(controllers.APITimersDiscard))
+	routesTimers.POST("/current/pause", echo.HandlerFunc(controllers.APITimersPause))
+	routesTimers.POST("/current/resume", echo.HandlerFunc(controllers.APITimersResume))
+	routesTimers.POST("/current/break", echo.HandlerFunc(controllers.APITimersBreak))
+	routesTimers.POST("/current/focus", echo.HandlerFunc(controllers.APITimersFocus))
+	routesTimers.POST("/current/stop", echo.HandlerFunc(controllers.APITimersStop))
+
+	routesStats := routesAPI.Group("/stats")
+	routesStats.Use(authenticated)
+	routesStats.GET("/reading", echo.HandlerFunc(controllers.APIStatsReading))
+	routesStats.GET("/pomodoros", echo.HandlerFunc(controllers.APIStatsPomodoros))
+	routesStats.GET("/tags", echo.HandlerFunc(controllers.APIStatsTags))
+	routesStats.GET("/time-of-day", echo.HandlerFunc(controllers.APIStatsTimeOfDay))
 
 	routesUser := routesAPI.Group("/user")
-	routesUser.Use(authenticated)
+	routesUser.Use(authenticated, controllers.Idempotent)
 	routesUser.GET("/:id", echo.HandlerFunc(controllers.APIUserGetByID))
 	routesUser.PUT("/:id", echo.HandlerFunc(controllers.APIUserUpdate))
 }
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"github.com/antonve/logger-api/models"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+)
+
+// StartLogSearchBackfill indexes the notes of logs that were saved before they could be searched,
+// returns a function that stops it after the current batch and waits for it
+func StartLogSearchBackfill(e *echo.Echo) func() {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		logCollection := models.LogCollection{}
+		indexed := int64(0)
+		for {
+			select {
+			case <-quit:
+				e.Logger.Infoj(gommonLog.JSON{"task": "backfill_log_search", "indexed": indexed, "stopped": true})
+				return
+			default:
+			}
+
+			batch, err := logCollection.BackfillSearchVectors()
+			if err != nil {
+				e.Logger.Errorj(gommonLog.JSON{"task": "backfill_log_search", "indexed": indexed, "error": err.Error()})
+				return
+			}
+			if batch == 0 {
+				e.Logger.Infoj(gommonLog.JSON{"task": "backfill_log_search", "indexed": indexed})
+				return
+			}
+
+			indexed += batch
+		}
+	}()
+
+	return func() {
+		close(quit)
+		<-done
+	}
+}
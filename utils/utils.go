@@ -5,6 +5,10 @@ import (
 	"io"
 	"log"
 	"os"
+	"time"
+
+	"github.com/antonve/logger-api/config"
+	"github.com/antonve/logger-api/controllers"
 
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
@@ -22,20 +26,22 @@ func SetupStaticAssets(e *echo.Echo) {
 	e.File("/", staticPath+"/index.html")
 }
 
-// SetupErrorLogging Log to both file and stderr
+// SetupErrorLogging Log structured JSON to both file and stderr
 func SetupErrorLogging(e *echo.Echo) func() {
 	// Create or open log file
-	logFile, err := os.OpenFile("error.log", os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
+	logFile, err := os.OpenFile(config.GetConfig().GetLogOutput(), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
 	if err != nil {
 		log.Panicln(err)
 	}
 
 	// Setup logging to stderr and our log file
-	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: "time=${time_rfc3339}, remote_ip=${remote_ip}, method=${method}, " +
-			"path=${path}, status=${status}, took=${response_time}, sent=t=${response_size} bytes\n",
-		Output: io.MultiWriter(os.Stderr, logFile),
-	}))
+	e.Logger.SetOutput(io.MultiWriter(os.Stderr, logFile))
+	e.Logger.SetLevel(config.GetConfig().GetLogLevel())
+
+	// Tag every request with an ID so log lines can be correlated,
+	// an `X-Request-ID` passed along by the client is reused
+	e.Use(middleware.RequestID())
+	e.Use(requestLogger)
 
 	// Return a function that will close the file handle once we exit the application
 	return func() {
@@ -45,3 +51,25 @@ func SetupErrorLogging(e *echo.Echo) func() {
 		}
 	}
 }
+
+// requestLogger logs every request once it has been handled
+func requestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(context echo.Context) error {
+		start := time.Now()
+
+		// Let echo write the error response first so we log the final status
+		if err := next(context); err != nil {
+			context.Error(err)
+		}
+
+		fields := controllers.LogFields(context)
+		fields["path"] = context.Request().URL.Path
+		fields["remote_ip"] = context.RealIP()
+		fields["status"] = context.Response().Status
+		fields["bytes_out"] = context.Response().Size
+		fields["latency_ms"] = float64(time.Since(start).Nanoseconds()) / float64(time.Millisecond)
+		context.Logger().Infoj(fields)
+
+		return nil
+	}
+}